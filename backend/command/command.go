@@ -2,18 +2,97 @@ package command
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"estonia-news/config"
 	"estonia-news/entity"
 	"estonia-news/misc"
+	"estonia-news/service"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/thoas/go-funk"
 )
 
+func formatTime(value time.Time) string {
+	if value.IsZero() {
+		return "never"
+	}
+	return value.Format(time.DateTime)
+}
+
+// formatDecision return a line of a decision about an item, a decision in a channel is marked by its name
+func formatDecision(decision entity.ItemDecision) string {
+	stage := decision.Stage
+	if decision.Channel != nil {
+		stage = fmt.Sprintf("%s@%s", stage, decision.Channel.Name)
+	}
+	return fmt.Sprintf("%s %s %s %s: %s", formatTime(decision.UpdatedAt), decision.EntryID, stage, decision.Decision, decision.Reason)
+}
+
+func formatParam(param string) string {
+	if param == "" {
+		return ""
+	}
+	return ":" + param
+}
+
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// parseFilterRule parse "<allow|block> <provider_id|0>[@<channel_id>] <field> <match>[:cs] <priority> <pattern>"
+func parseFilterRule(command string) (*entity.FilterRule, error) {
+	args := strings.SplitN(command, " ", 6)
+	if len(args) != 6 {
+		return nil, errors.New("usage: /add_rule <allow|block> <provider_id|0>[@<channel_id>] <field> <match>[:cs] <priority> <pattern>")
+	}
+	if args[0] != entity.FilterActionAllow && args[0] != entity.FilterActionBlock {
+		return nil, fmt.Errorf("unknown action '%s'", args[0])
+	}
+	provider, channel, found := strings.Cut(args[1], "@")
+	providerID, err := strconv.Atoi(provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse provider id: %v", err)
+	}
+	channelID := 0
+	if found {
+		if channelID, err = strconv.Atoi(channel); err != nil {
+			return nil, fmt.Errorf("failed to parse channel id: %v", err)
+		}
+	}
+	if !funk.ContainsString([]string{entity.FilterFieldTitle, entity.FilterFieldDescription, entity.FilterFieldText, entity.FilterFieldLink, entity.FilterFieldCategory, entity.FilterFieldAuthor, entity.FilterFieldAny}, args[2]) {
+		return nil, fmt.Errorf("unknown field '%s'", args[2])
+	}
+	match, option, _ := strings.Cut(args[3], ":")
+	priority, err := strconv.Atoi(args[4])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse priority: %v", err)
+	}
+	rule := &entity.FilterRule{
+		ProviderID:    providerID,
+		ChannelID:     channelID,
+		Action:        args[0],
+		Field:         args[2],
+		Match:         match,
+		CaseSensitive: option == "cs",
+		Priority:      priority,
+		Pattern:       args[5],
+	}
+	if _, err := service.CompileFilterRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
 // ExecCommand is exec command
 func ExecCommand(ctx context.Context, message *tgbotapi.Message) {
 	msg := tgbotapi.NewMessage(message.Chat.ID, "")
@@ -62,6 +141,557 @@ func ExecCommand(ctx context.Context, message *tgbotapi.Message) {
 		msg.Text = strings.Join(funk.Map(res, func(block entity.BlockedCategory) string {
 			return fmt.Sprintf("%d %s %s", block.CategoryID, block.Category.Name, block.Category.Provider.Lang)
 		}).([]string), "\n")
+	case "topics":
+		res, err := entity.GetTopics(ctx)
+		if err != nil {
+			misc.Error("exec_command", "topics", err)
+			return
+		}
+		msg.Text = "no topics found"
+		if len(res) > 0 {
+			msg.Text = strings.Join(funk.Map(res, func(topic entity.Topic) string {
+				return fmt.Sprintf("%d %s %s", topic.ID, topic.Name, topic.Hashtag)
+			}).([]string), "\n")
+		}
+	case "add_topic":
+		args := strings.Fields(command)
+		if len(args) < 2 {
+			misc.Error("exec_command", "add topic", errors.New("usage: /add_topic <hashtag> <name>"))
+			return
+		}
+		err := entity.AddTopic(ctx, strings.Join(args[1:], " "), args[0])
+		if err != nil {
+			misc.Error("exec_command", "add topic", err)
+			return
+		}
+		msg.Text = "done"
+	case "delete_topic":
+		topicID, err := strconv.Atoi(command)
+		if err != nil {
+			misc.Error("exec_command", "delete topic", err)
+			return
+		}
+		err = entity.DeleteTopic(ctx, topicID)
+		if err != nil {
+			misc.Error("exec_command", "delete topic", err)
+			return
+		}
+		msg.Text = "done"
+	case "map_topic", "unmap_topic":
+		args := strings.Fields(command)
+		if len(args) != 2 {
+			misc.Error("exec_command", "map topic", fmt.Errorf("usage: /%s <category_id> <topic_id>", message.Command()))
+			return
+		}
+		categoryID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "map topic", err)
+			return
+		}
+		topicID, err := strconv.Atoi(args[1])
+		if err != nil {
+			misc.Error("exec_command", "map topic", err)
+			return
+		}
+		if message.Command() == "map_topic" {
+			err = entity.MapCategoryToTopic(ctx, categoryID, topicID)
+		} else {
+			err = entity.UnmapCategoryFromTopic(ctx, categoryID, topicID)
+		}
+		if err != nil {
+			misc.Error("exec_command", "map topic", err)
+			return
+		}
+		msg.Text = "done"
+	case "categories":
+		providerID, err := strconv.Atoi(command)
+		if err != nil {
+			misc.Error("exec_command", "categories", err)
+			return
+		}
+		categories, topics, err := entity.GetCategoriesByProvider(ctx, providerID)
+		if err != nil {
+			misc.Error("exec_command", "categories", err)
+			return
+		}
+		msg.Text = "no categories found"
+		if len(categories) > 0 {
+			msg.Text = strings.Join(funk.Map(categories, func(category entity.Category) string {
+				names := funk.Map(topics[category.ID], func(topic entity.Topic) string {
+					return topic.Name
+				}).([]string)
+				if len(names) == 0 {
+					return fmt.Sprintf("%d %s", category.ID, category.Name)
+				}
+				return fmt.Sprintf("%d %s: %s", category.ID, category.Name, strings.Join(names, ", "))
+			}).([]string), "\n")
+		}
+	case "providers":
+		res, err := entity.GetProviders(ctx)
+		if err != nil {
+			misc.Error("exec_command", "providers", err)
+			return
+		}
+		msg.Text = strings.Join(funk.Map(res, func(provider entity.Provider) string {
+			text := fmt.Sprintf("%d %s %s priority %d every %ds, retraction %s, paywall %s, last run %s, next run %s", provider.ID, provider.Name, provider.Lang, provider.Priority, provider.PollInterval, provider.RetractionPolicy, provider.PaywallPolicy, formatTime(provider.LastRunAt), formatTime(provider.NextRunAt))
+			if provider.PaywallChatID != 0 {
+				text += fmt.Sprintf(" to chat %d", provider.PaywallChatID)
+			}
+			if provider.FailureCount > 0 {
+				text += fmt.Sprintf(", %d failures, last error: %s", provider.FailureCount, provider.LastError)
+			}
+			if !provider.CircuitOpenUntil.IsZero() {
+				text += fmt.Sprintf(", paused until %s", formatTime(provider.CircuitOpenUntil))
+			}
+			return text
+		}).([]string), "\n")
+	case "channels":
+		res, err := entity.GetChannels(ctx)
+		if err != nil {
+			misc.Error("exec_command", "channels", err)
+			return
+		}
+		msg.Text = strings.Join(funk.Map(res, func(channel entity.Channel) string {
+			text := fmt.Sprintf("%d %s chat %d from %s", channel.ID, channel.Name, channel.ChatID, strings.Join(channel.SourceLangs, ","))
+			if channel.TranslateLang != "" {
+				text += fmt.Sprintf(" to %s", channel.TranslateLang)
+			}
+			if channel.PaywallChatID != 0 {
+				text += fmt.Sprintf(", paywall to chat %d", channel.PaywallChatID)
+			}
+			return text
+		}).([]string), "\n")
+	case "add_channel":
+		args := strings.Fields(command)
+		if len(args) < 2 || len(args) > 4 {
+			misc.Error("exec_command", "add channel", errors.New("usage: /add_channel <chat_id> <source_lang,...> [translate_lang|-] [name]"))
+			return
+		}
+		chatID, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			misc.Error("exec_command", "add channel", err)
+			return
+		}
+		channel := &entity.Channel{Name: args[0], ChatID: chatID, SourceLangs: strings.Split(args[1], ",")}
+		if len(args) > 2 && args[2] != "-" {
+			channel.TranslateLang = args[2]
+		}
+		if len(args) > 3 {
+			channel.Name = args[3]
+		}
+		err = entity.AddChannel(ctx, channel)
+		if err != nil {
+			misc.Error("exec_command", "add channel", err)
+			return
+		}
+		msg.Text = "done"
+	case "set_channel_paywall":
+		args := strings.Fields(command)
+		if len(args) != 2 {
+			misc.Error("exec_command", "set channel paywall", errors.New("usage: /set_channel_paywall <channel_id> <chat_id|0>"))
+			return
+		}
+		channelID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "set channel paywall", err)
+			return
+		}
+		chatID, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			misc.Error("exec_command", "set channel paywall", err)
+			return
+		}
+		err = entity.SetChannelPaywallChatID(ctx, channelID, chatID)
+		if err != nil {
+			misc.Error("exec_command", "set channel paywall", err)
+			return
+		}
+		msg.Text = "done"
+	case "delete_channel":
+		channelID, err := strconv.Atoi(command)
+		if err != nil {
+			misc.Error("exec_command", "delete channel", err)
+			return
+		}
+		err = entity.DeleteChannel(ctx, channelID)
+		if err != nil {
+			misc.Error("exec_command", "delete channel", err)
+			return
+		}
+		msg.Text = "done"
+	case "set_priority":
+		args := strings.Fields(command)
+		if len(args) != 2 {
+			misc.Error("exec_command", "set priority", errors.New("usage: /set_priority <provider_id> <priority>"))
+			return
+		}
+		providerID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "set priority", err)
+			return
+		}
+		priority, err := strconv.Atoi(args[1])
+		if err != nil {
+			misc.Error("exec_command", "set priority", err)
+			return
+		}
+		err = entity.SetProviderPriority(ctx, providerID, priority)
+		if err != nil {
+			misc.Error("exec_command", "set priority", err)
+			return
+		}
+		msg.Text = "done"
+	case "set_retraction":
+		args := strings.Fields(command)
+		if len(args) != 2 {
+			misc.Error("exec_command", "set retraction", errors.New("usage: /set_retraction <provider_id> <delete|strike|keep>"))
+			return
+		}
+		providerID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "set retraction", err)
+			return
+		}
+		err = entity.SetProviderRetractionPolicy(ctx, providerID, args[1])
+		if err != nil {
+			misc.Error("exec_command", "set retraction", err)
+			return
+		}
+		msg.Text = "done"
+	case "set_availability":
+		args := strings.SplitN(command, " ", 2)
+		if len(args) != 2 {
+			misc.Error("exec_command", "set availability", errors.New("usage: /set_availability <provider_id> <json|default>"))
+			return
+		}
+		providerID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "set availability", err)
+			return
+		}
+		var availability *entity.Availability
+		if strings.TrimSpace(args[1]) != "default" {
+			availability = &entity.Availability{}
+			if err := json.Unmarshal([]byte(args[1]), availability); err != nil {
+				misc.Error("exec_command", "set availability", err)
+				return
+			}
+		}
+		err = entity.SetProviderAvailability(ctx, providerID, availability)
+		if err != nil {
+			misc.Error("exec_command", "set availability", err)
+			return
+		}
+		msg.Text = "done"
+	case "set_paywall":
+		args := strings.SplitN(command, " ", 2)
+		if len(args) != 2 {
+			misc.Error("exec_command", "set paywall", errors.New("usage: /set_paywall <provider_id> <json|default>"))
+			return
+		}
+		providerID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "set paywall", err)
+			return
+		}
+		var paywall *entity.PaywallRules
+		if strings.TrimSpace(args[1]) != "default" {
+			paywall = &entity.PaywallRules{}
+			if err := json.Unmarshal([]byte(args[1]), paywall); err != nil {
+				misc.Error("exec_command", "set paywall", err)
+				return
+			}
+		}
+		err = entity.SetProviderPaywall(ctx, providerID, paywall)
+		if err != nil {
+			misc.Error("exec_command", "set paywall", err)
+			return
+		}
+		msg.Text = "done"
+	case "set_paywall_policy":
+		args := strings.Fields(command)
+		if len(args) != 2 && len(args) != 3 {
+			misc.Error("exec_command", "set paywall policy", errors.New("usage: /set_paywall_policy <provider_id> <publish|skip|route> [chat_id]"))
+			return
+		}
+		providerID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "set paywall policy", err)
+			return
+		}
+		var chatID int64
+		if len(args) == 3 {
+			chatID, err = strconv.ParseInt(args[2], 10, 64)
+			if err != nil {
+				misc.Error("exec_command", "set paywall policy", err)
+				return
+			}
+		}
+		err = entity.SetProviderPaywallPolicy(ctx, providerID, args[1], chatID)
+		if err != nil {
+			misc.Error("exec_command", "set paywall policy", err)
+			return
+		}
+		msg.Text = "done"
+	case "retracted":
+		res, err := entity.GetRetractedEntries(ctx, 20)
+		if err != nil {
+			misc.Error("exec_command", "retracted", err)
+			return
+		}
+		msg.Text = "no retracted entries found"
+		if len(res) > 0 {
+			msg.Text = strings.Join(funk.Map(res, func(entry entity.RetractedEntry) string {
+				return fmt.Sprintf("%s %s %s %s: %s %s", formatTime(entry.CreatedAt), entry.EntryID, entry.Provider.Name, entry.Policy, entry.Title, entry.Link)
+			}).([]string), "\n")
+		}
+	case "add_guid_rule":
+		args := strings.SplitN(command, " ", 4)
+		if len(args) != 4 {
+			misc.Error("exec_command", "add guid rule", errors.New("usage: /add_guid_rule <provider_id> <guid|link|query:param> <prefix> <pattern>"))
+			return
+		}
+		providerID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "add guid rule", err)
+			return
+		}
+		field, param, _ := strings.Cut(args[1], ":")
+		err = entity.AddGUIDRule(ctx, &entity.GUIDRule{ProviderID: providerID, Field: field, Param: param, Prefix: args[2], Pattern: args[3]})
+		if err != nil {
+			misc.Error("exec_command", "add guid rule", err)
+			return
+		}
+		msg.Text = "done"
+	case "delete_guid_rule":
+		ruleID, err := strconv.Atoi(command)
+		if err != nil {
+			misc.Error("exec_command", "delete guid rule", err)
+			return
+		}
+		err = entity.DeleteGUIDRule(ctx, ruleID)
+		if err != nil {
+			misc.Error("exec_command", "delete guid rule", err)
+			return
+		}
+		msg.Text = "done"
+	case "list_guid_rules":
+		res, err := entity.GetGUIDRules(ctx)
+		if err != nil {
+			misc.Error("exec_command", "list guid rules", err)
+			return
+		}
+		msg.Text = strings.Join(funk.Map(res, func(rule entity.GUIDRule) string {
+			return fmt.Sprintf("%d provider %d %s%s %s# %s", rule.ID, rule.ProviderID, rule.Field, formatParam(rule.Param), rule.Prefix, rule.Pattern)
+		}).([]string), "\n")
+	case "add_rule":
+		rule, err := parseFilterRule(command)
+		if err != nil {
+			misc.Error("exec_command", "add rule", err)
+			return
+		}
+		err = entity.AddFilterRule(ctx, rule)
+		if err != nil {
+			misc.Error("exec_command", "add rule", err)
+			return
+		}
+		msg.Text = "done"
+	case "delete_rule":
+		ruleID, err := strconv.Atoi(command)
+		if err != nil {
+			misc.Error("exec_command", "delete rule", err)
+			return
+		}
+		err = entity.DeleteFilterRule(ctx, ruleID)
+		if err != nil {
+			misc.Error("exec_command", "delete rule", err)
+			return
+		}
+		msg.Text = "done"
+	case "list_rules":
+		res, err := entity.GetListFilterRules(ctx)
+		if err != nil {
+			misc.Error("exec_command", "list rules", err)
+			return
+		}
+		msg.Text = strings.Join(funk.Map(res, func(rule entity.FilterRule) string {
+			scope := "global"
+			if rule.ProviderID != 0 {
+				scope = fmt.Sprintf("provider %d", rule.ProviderID)
+			}
+			if rule.ChannelID != 0 {
+				scope += fmt.Sprintf(" channel %d", rule.ChannelID)
+			}
+			match := rule.Match
+			if rule.CaseSensitive {
+				match += ":cs"
+			}
+			return fmt.Sprintf("%d %s %s %s %s priority %d hits %d: %s", rule.ID, rule.Action, scope, rule.Field, match, rule.Priority, rule.Hits, rule.Pattern)
+		}).([]string), "\n")
+	case "add_term":
+		args := strings.SplitN(command, " ", 3)
+		if len(args) != 3 {
+			misc.Error("exec_command", "add term", errors.New("usage: /add_term <source_lang> <target_lang> <term> = <translation>"))
+			return
+		}
+		term, translation, ok := strings.Cut(args[2], " = ")
+		if !ok || strings.TrimSpace(term) == "" || strings.TrimSpace(translation) == "" {
+			misc.Error("exec_command", "add term", errors.New("usage: /add_term <source_lang> <target_lang> <term> = <translation>"))
+			return
+		}
+		err := entity.AddGlossaryTerm(ctx, &entity.GlossaryTerm{
+			SourceLang:  service.LanguageCode(args[0]),
+			TargetLang:  service.LanguageCode(args[1]),
+			Term:        strings.TrimSpace(term),
+			Translation: strings.TrimSpace(translation),
+		})
+		if err != nil {
+			misc.Error("exec_command", "add term", err)
+			return
+		}
+		msg.Text = "done"
+	case "protect":
+		lang, term, ok := strings.Cut(command, " ")
+		if !ok || strings.TrimSpace(term) == "" {
+			misc.Error("exec_command", "protect", errors.New("usage: /protect <source_lang> <term>"))
+			return
+		}
+		err := entity.AddGlossaryTerm(ctx, &entity.GlossaryTerm{
+			SourceLang: service.LanguageCode(lang),
+			Term:       strings.TrimSpace(term),
+		})
+		if err != nil {
+			misc.Error("exec_command", "protect", err)
+			return
+		}
+		msg.Text = "done"
+	case "delete_term":
+		termID, err := strconv.Atoi(command)
+		if err != nil {
+			misc.Error("exec_command", "delete term", err)
+			return
+		}
+		err = entity.DeleteGlossaryTerm(ctx, termID)
+		if err != nil {
+			misc.Error("exec_command", "delete term", err)
+			return
+		}
+		msg.Text = "done"
+	case "list_terms":
+		res, err := entity.GetListGlossaryTerms(ctx)
+		if err != nil {
+			misc.Error("exec_command", "list terms", err)
+			return
+		}
+		msg.Text = "no terms found"
+		if len(res) > 0 {
+			msg.Text = strings.Join(funk.Map(res, func(term entity.GlossaryTerm) string {
+				if term.Translation == "" {
+					return fmt.Sprintf("%d %s protected: %s", term.ID, term.SourceLang, term.Term)
+				}
+				return fmt.Sprintf("%d %s-%s: %s = %s", term.ID, term.SourceLang, term.TargetLang, term.Term, term.Translation)
+			}).([]string), "\n")
+		}
+	case "set_template":
+		args := strings.SplitN(command, " ", 4)
+		if len(args) != 4 {
+			misc.Error("exec_command", "set template", errors.New("usage: /set_template <provider_id|0> <channel_id|0> <text|button> <template>"))
+			return
+		}
+		providerID, err := strconv.Atoi(args[0])
+		if err != nil {
+			misc.Error("exec_command", "set template", err)
+			return
+		}
+		channelID, err := strconv.Atoi(args[1])
+		if err != nil {
+			misc.Error("exec_command", "set template", err)
+			return
+		}
+		if err := service.ValidateTemplate(args[2], args[3]); err != nil {
+			msg.Text = fmt.Sprintf("invalid template: %v", err)
+			break
+		}
+		err = entity.SetTemplate(ctx, &entity.Template{ProviderID: providerID, ChannelID: channelID, Name: args[2], Body: args[3]})
+		if err != nil {
+			misc.Error("exec_command", "set template", err)
+			return
+		}
+		msg.Text = "done"
+	case "delete_template":
+		templateID, err := strconv.Atoi(command)
+		if err != nil {
+			misc.Error("exec_command", "delete template", err)
+			return
+		}
+		err = entity.DeleteTemplate(ctx, templateID)
+		if err != nil {
+			misc.Error("exec_command", "delete template", err)
+			return
+		}
+		msg.Text = "done"
+	case "list_templates":
+		res, err := entity.GetListTemplates(ctx)
+		if err != nil {
+			misc.Error("exec_command", "list templates", err)
+			return
+		}
+		msg.Text = "no templates found"
+		if len(res) > 0 {
+			msg.Text = strings.Join(funk.Map(res, func(template entity.Template) string {
+				return fmt.Sprintf("%d provider %d channel %d %s: %s", template.ID, template.ProviderID, template.ChannelID, template.Name, template.Body)
+			}).([]string), "\n")
+		}
+	case "history":
+		entry, err := entity.GetEntryByID(ctx, command)
+		if err != nil {
+			misc.Error("exec_command", "history", err)
+			return
+		}
+		revisions, err := entity.GetEntryRevisions(ctx, entry.ID)
+		if err != nil {
+			misc.Error("exec_command", "history", err)
+			return
+		}
+		lines := []string{entry.ID}
+		for i, revision := range revisions {
+			if i == 0 {
+				lines = append(lines, fmt.Sprintf("%s\n%s\n%s", formatTime(revision.CreatedAt), revision.Title, revision.Description))
+				continue
+			}
+			previous := revisions[i-1]
+			lines = append(lines, fmt.Sprintf("%s\n%s\n%s", formatTime(revision.CreatedAt), service.DiffWords(previous.Title, revision.Title), service.DiffWords(previous.Description, revision.Description)))
+			if previous.Link != revision.Link {
+				lines = append(lines, fmt.Sprintf("link: %s", revision.Link))
+			}
+			if previous.ImageURL != revision.ImageURL {
+				lines = append(lines, fmt.Sprintf("image: %s", revision.ImageURL))
+			}
+		}
+		decisions, err := entity.GetDecisions(ctx, entry.ID)
+		if err != nil {
+			misc.Error("exec_command", "history", err)
+			return
+		}
+		for _, decision := range decisions {
+			if decision.Channel != nil {
+				lines = append(lines, formatDecision(decision))
+			}
+		}
+		msg.Text = truncateText(strings.Join(lines, "\n\n"), config.MessageLimit)
+	case "why":
+		if command == "" {
+			return
+		}
+		res, err := entity.GetDecisions(ctx, command)
+		if err != nil {
+			misc.Error("exec_command", "why", err)
+			return
+		}
+		msg.Text = "no decisions found"
+		if len(res) > 0 {
+			msg.Text = strings.Join(funk.Map(res, formatDecision).([]string), "\n")
+		}
 	default:
 		return
 	}
@@ -2,20 +2,127 @@ package config
 
 import (
 	"time"
+	_ "time/tzdata" // time zones of posts without the system database
 )
 
-// TimeoutBetweenLoops is main loop timeout
+// TimeoutBetweenLoops is default poll interval of a provider
 var TimeoutBetweenLoops = 5 * time.Minute
 
+// SchedulerTick is how often providers are checked for a due poll
+var SchedulerTick = 15 * time.Second
+
+// FetchWorkers is number of feeds fetched at the same time
+var FetchWorkers = 4
+
+// RetryBackoffBase is delay before the first retry of a failed provider
+var RetryBackoffBase = 30 * time.Second
+
+// RetryBackoffMax is the longest delay between retries of a failed provider
+var RetryBackoffMax = 30 * time.Minute
+
+// CircuitBreakerThreshold is number of failures in a row to pause a provider
+var CircuitBreakerThreshold = 5
+
+// CircuitBreakerTimeout is time to pause a provider after too many failures
+var CircuitBreakerTimeout = time.Hour
+
 // TimeoutBetweenMessages is timeout between attempts to send a message
 var TimeoutBetweenMessages = time.Second
 
+// OutboxTick is how often the outbox is checked for due messages
+var OutboxTick = 5 * time.Second
+
+// OutboxBatchSize is max number of outbox messages sent per tick
+var OutboxBatchSize = 20
+
+// OutboxMaxAttempts is number of failed attempts to give up an outbox message
+var OutboxMaxAttempts = 8
+
+// OutboxRetryDelay is delay before the first retry of a failed outbox message
+var OutboxRetryDelay = 10 * time.Second
+
+// OutboxRetryDelayMax is the longest delay between retries of a failed outbox message
+var OutboxRetryDelayMax = 10 * time.Minute
+
+// OutboxRecordAttempts is number of attempts to record a sent message before its outbox message is given up
+var OutboxRecordAttempts = 3
+
+// OutboxLease is how long claimed outbox messages are hidden from the other workers, a message left sending
+// longer is checked as interrupted
+var OutboxLease = 5 * time.Minute
+
+// OutboxRetention is how long sent and failed outbox messages are kept
+var OutboxRetention = 48 * time.Hour
+
+// TimeZone is the time zone of times shown in posts
+var TimeZone, _ = time.LoadLocation("Europe/Tallinn")
+
+// UpdatedMarker is whether edited posts are marked with the time of the last update
+var UpdatedMarker bool
+
+// SignificantFields are the changed fields of an item which cause an edit of its message
+var SignificantFields = map[string]bool{
+	"title":       true,
+	"description": true,
+	"link":        true,
+	"image":       true,
+	"paywall":     true,
+}
+
+// MinEditInterval is the minimum time between edits of the same message
+var MinEditInterval = 10 * time.Minute
+
+// CaptionLimit is the max length of a photo caption
+const CaptionLimit = 1024
+
+// MessageLimit is the max length of a text message
+const MessageLimit = 4096
+
+// ImageMaxSize is the max size of an uploaded photo, bigger images are downscaled
+var ImageMaxSize = 10 << 20
+
+// ImageMaxPixels is the max number of pixels of an image to decode
+var ImageMaxPixels = 50_000_000
+
+// ImageMaxDimension is the max width and height of an uploaded photo, bigger images are downscaled
+var ImageMaxDimension = 2560
+
+// ImageMinWidth is the min width of a usable image
+var ImageMinWidth = 200
+
+// ImageMinHeight is the min height of a usable image
+var ImageMinHeight = 100
+
+// ImageQuality is the JPEG quality of re-encoded images
+var ImageQuality = 85
+
+// ImageCacheSize is number of the last prepared images kept to upload them without downloading again
+var ImageCacheSize = 16
+
 // TimeShift get messages from the last hours
 var TimeShift = 2 * time.Hour
 
 // PurgeOldEntriesEvery is time for purge old entries
 var PurgeOldEntriesEvery = time.Hour
 
+// SimilarityThreshold is minimal similarity of titles to group entries into a story
+var SimilarityThreshold = 0.3
+
+// SimilarityWindow is how long a story accepts entries of other providers
+var SimilarityWindow = 24 * time.Hour
+
+// HoldWindow is how long a new item waits for duplicates of other providers before publishing, zero disables holding
+var HoldWindow time.Duration
+
+// DecisionRetention is how long decisions about items are kept
+var DecisionRetention = 48 * time.Hour
+
+// ListingItemRetention is how long undated listing items are remembered after they are last seen
+var ListingItemRetention = 7 * 24 * time.Hour
+
+// TranslationRetention is how long cached translations are kept
+var TranslationRetention = 30 * 24 * time.Hour
+
 // PushMetricsEvery is time for push metrics
 var PushMetricsEvery = 5 * time.Second
 
@@ -26,6 +133,7 @@ type FeedItem struct {
 	Title         string
 	ImageURL      string
 	Description   string
+	Author        string
 	Published     string
 	Categories    []string
 	CategoriesIDs []int
@@ -47,4 +155,12 @@ const (
 	CtxFeedTitleKey
 	// CtxTranslateLangKey is ctx translate lang key
 	CtxTranslateLangKey
+	// CtxAdminChatIDKey is ctx admin chat id key
+	CtxAdminChatIDKey
+	// CtxTranslatorKey is ctx translator key
+	CtxTranslatorKey
+	// CtxChannelsKey is ctx key of channels of the provider
+	CtxChannelsKey
+	// CtxChannelKey is ctx key of the channel a message is published to
+	CtxChannelKey
 )
@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// GetChannels return list channels
+func GetChannels(ctx context.Context) ([]Channel, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var channels []Channel
+	err := dbConnect.NewSelect().Model(&channels).Order("ch.id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of channels: %v", err)
+	}
+	return channels, nil
+}
+
+// GetChannelsByLang return channels publishing items of providers of lang
+func GetChannelsByLang(ctx context.Context, lang string) ([]*Channel, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var channels []*Channel
+	err := dbConnect.NewSelect().Model(&channels).Where("? = ANY(ch.source_langs)", lang).Order("ch.id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channels of lang '%s': %v", lang, err)
+	}
+	return channels, nil
+}
+
+// AddChannel add channel or update the channel of the same chat
+func AddChannel(ctx context.Context, channel *Channel) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewInsert().Model(channel).
+		On("CONFLICT (chat_id) DO UPDATE").
+		Set("name = EXCLUDED.name, source_langs = EXCLUDED.source_langs, translate_lang = EXCLUDED.translate_lang").
+		Returning("id").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add channel of chat %d: %v", channel.ChatID, err)
+	}
+	return nil
+}
+
+// EnsureChannel add channel if there is no channel of its chat
+func EnsureChannel(ctx context.Context, channel *Channel) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewInsert().Model(channel).On("CONFLICT (chat_id) DO NOTHING").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add channel of chat %d: %v", channel.ChatID, err)
+	}
+	return nil
+}
+
+// SetChannelPaywallChatID set chat of paywalled items of the channel, zero chat reset it to the one of the provider
+func SetChannelPaywallChatID(ctx context.Context, channelID int, chatID int64) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(&Channel{}).Set("paywall_chat_id = ?", bun.NullZero(chatID)).Where("id = ?", channelID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set paywall chat of channel %d: %v", channelID, err)
+	}
+	return nil
+}
+
+// DeleteChannel delete channel
+func DeleteChannel(ctx context.Context, channelID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(&Channel{}).Where("id = ?", channelID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete channel %d: %v", channelID, err)
+	}
+	return nil
+}
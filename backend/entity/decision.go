@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// SaveDecision store the decision about an item at a stage in a channel, the previous decision at the stage
+// in the channel is replaced, true is returned when there is no previous decision
+func SaveDecision(ctx context.Context, decision *ItemDecision) (bool, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var inserted bool
+	err := dbConnect.NewInsert().Model(decision).
+		On("CONFLICT (entry_id, stage, COALESCE(channel_id, 0)) DO UPDATE").
+		Returning("xmax = 0").Scan(ctx, &inserted)
+	if err != nil {
+		return false, fmt.Errorf("failed to save decision about '%s' at stage '%s': %v", decision.EntryID, decision.Stage, err)
+	}
+	return inserted, nil
+}
+
+// GetDecisions return decisions about items found by GUID or link
+func GetDecisions(ctx context.Context, query string) ([]ItemDecision, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var decisions []ItemDecision
+	err := dbConnect.NewSelect().Model(&decisions).Relation("Channel").
+		Where("d.entry_id LIKE ? OR d.link = ?", fmt.Sprintf("%s%s%s", "%", query, "%"), query).
+		Order("d.entry_id", "d.updated_at").Limit(50).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decisions about '%s': %v", query, err)
+	}
+	return decisions, nil
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// GetFilterRules return global filter rules and filter rules of provider, the rules of all channels
+// if channel is zero and the rules of the channel otherwise
+func GetFilterRules(ctx context.Context, providerID, channelID int) ([]*FilterRule, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var rules []*FilterRule
+	query := dbConnect.NewSelect().Model(&rules).Where("provider_id IS NULL OR provider_id = ?", providerID)
+	if channelID == 0 {
+		query = query.Where("channel_id IS NULL")
+	} else {
+		query = query.Where("channel_id = ?", channelID)
+	}
+	err := query.Order("priority DESC", "id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter rules for provider %d: %v", providerID, err)
+	}
+	return rules, nil
+}
+
+// GetListFilterRules return list filter rules
+func GetListFilterRules(ctx context.Context) ([]FilterRule, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var rules []FilterRule
+	err := dbConnect.NewSelect().Model(&rules).Order("priority DESC", "id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of filter rules: %v", err)
+	}
+	return rules, nil
+}
+
+// AddFilterRule add filter rule
+func AddFilterRule(ctx context.Context, rule *FilterRule) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewInsert().Model(rule).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add filter rule: %v", err)
+	}
+	return nil
+}
+
+// DeleteFilterRule delete filter rule
+func DeleteFilterRule(ctx context.Context, ruleID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(&FilterRule{}).Where("id = ?", ruleID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete filter rule %d: %v", ruleID, err)
+	}
+	return nil
+}
+
+// CountFilterRuleHit increment hits of filter rule
+func CountFilterRuleHit(ctx context.Context, ruleID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(&FilterRule{}).Set("hits = hits + 1").Where("id = ?", ruleID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count hit of filter rule %d: %v", ruleID, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// GetGlossaryTerms return glossary terms of the language pair including terms of any target language
+func GetGlossaryTerms(ctx context.Context, sourceLang, targetLang string) ([]*GlossaryTerm, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var terms []*GlossaryTerm
+	err := dbConnect.NewSelect().Model(&terms).
+		Where("source_lang = ?", sourceLang).
+		Where("target_lang = ? OR target_lang = ''", targetLang).
+		Order("id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get glossary terms from '%s' to '%s': %v", sourceLang, targetLang, err)
+	}
+	return terms, nil
+}
+
+// GetListGlossaryTerms return list glossary terms
+func GetListGlossaryTerms(ctx context.Context) ([]GlossaryTerm, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var terms []GlossaryTerm
+	err := dbConnect.NewSelect().Model(&terms).Order("source_lang", "target_lang", "term").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of glossary terms: %v", err)
+	}
+	return terms, nil
+}
+
+// AddGlossaryTerm add glossary term, the translation of an existing term is replaced
+func AddGlossaryTerm(ctx context.Context, term *GlossaryTerm) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	if term.SourceLang == "" || term.Term == "" {
+		return fmt.Errorf("failed to add glossary term '%s': empty source language or term", term.Term)
+	}
+	_, err := dbConnect.NewInsert().Model(term).
+		On("CONFLICT (source_lang, target_lang, term) DO UPDATE").
+		Set("translation = EXCLUDED.translation").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add glossary term '%s': %v", term.Term, err)
+	}
+	return nil
+}
+
+// DeleteGlossaryTerm delete glossary term
+func DeleteGlossaryTerm(ctx context.Context, termID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(&GlossaryTerm{}).Where("id = ?", termID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete glossary term %d: %v", termID, err)
+	}
+	return nil
+}
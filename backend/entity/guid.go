@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// AddGUIDRule add rule of GUID extraction to provider
+func AddGUIDRule(ctx context.Context, rule *GUIDRule) error {
+	r, err := regexp.Compile(rule.Pattern)
+	if err != nil {
+		return fmt.Errorf("failed to add GUID rule for provider %d: %v", rule.ProviderID, err)
+	}
+	if r.NumSubexp() == 0 {
+		return fmt.Errorf("failed to add GUID rule for provider %d: pattern has no capture group", rule.ProviderID)
+	}
+	switch rule.Field {
+	case GUIDFieldGUID, GUIDFieldLink:
+	case GUIDFieldQuery:
+		if rule.Param == "" {
+			return fmt.Errorf("failed to add GUID rule for provider %d: empty query param", rule.ProviderID)
+		}
+	default:
+		return fmt.Errorf("failed to add GUID rule for provider %d: unknown field '%s'", rule.ProviderID, rule.Field)
+	}
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err = dbConnect.NewInsert().Model(rule).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add GUID rule for provider %d: %v", rule.ProviderID, err)
+	}
+	return nil
+}
+
+// DeleteGUIDRule delete rule of GUID extraction
+func DeleteGUIDRule(ctx context.Context, ruleID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(&GUIDRule{}).Where("id = ?", ruleID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete GUID rule %d: %v", ruleID, err)
+	}
+	return nil
+}
+
+// GetGUIDRules return list rules of GUID extraction
+func GetGUIDRules(ctx context.Context) ([]GUIDRule, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var rules []GUIDRule
+	err := dbConnect.NewSelect().Model(&rules).Order("provider_id", "priority DESC", "id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of GUID rules: %v", err)
+	}
+	return rules, nil
+}
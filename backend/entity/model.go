@@ -3,6 +3,8 @@ package entity
 import (
 	"time"
 
+	"estonia-news/config"
+
 	"github.com/uptrace/bun"
 )
 
@@ -15,26 +17,144 @@ type Entry struct {
 	Title       string
 	Description string
 	ImageURL    string
+	ImageFileID string `bun:",nullzero"`
 	Paywall     bool
-	MessageID   int
 	ProviderID  int
 	UpdatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp"`
 	PublishedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	EditedAt    time.Time `bun:",nullzero"`
+	RetractedAt time.Time `bun:",nullzero"`
 
 	Provider   *Provider          `bun:"rel:has-one,join:provider_id=id"`
 	Categories []*EntryToCategory `bun:"rel:has-many,join:id=entry_id"`
+	Messages   []*EntryMessage    `bun:"rel:has-many,join:id=entry_id"`
+}
+
+// Channel is a Telegram chat publishing items of providers of its source languages,
+// templates of the channel are the templates of its chat
+type Channel struct {
+	bun.BaseModel `bun:"table:channels,alias:ch"`
+
+	ID            int `bun:",pk,autoincrement"`
+	Name          string
+	ChatID        int64
+	SourceLangs   []string `bun:",array"`
+	TranslateLang string   `bun:",nullzero"`
+	PaywallChatID int64    `bun:",nullzero"`
+}
+
+// EntryMessage is a Telegram message of an entry in a channel
+type EntryMessage struct {
+	bun.BaseModel `bun:"table:entry_messages,alias:em"`
+
+	EntryID     string `bun:",pk"`
+	ChannelID   int    `bun:",pk"`
+	ChatID      int64
+	MessageID   int
+	MessageType string    `bun:",nullzero,notnull,default:'text'"`
+	CreatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Channel *Channel `bun:"rel:has-one,join:channel_id=id"`
 }
 
+// Types of a Telegram message of an entry
+const (
+	MessageTypeText  = "text"
+	MessageTypePhoto = "photo"
+)
+
 // Provider is a provider structure
 type Provider struct {
 	bun.BaseModel `bun:"table:providers,alias:p"`
 
-	ID             int `bun:",pk,autoincrement"`
-	Name           string
-	URL            string
-	Lang           string
-	BlockedWords   []string `bun:",array"`
-	BlockedDomains []string `bun:",array"`
+	ID               int `bun:",pk,autoincrement"`
+	Name             string
+	URL              string
+	Lang             string
+	Type             string     `bun:",nullzero,notnull,default:'rss'"`
+	Selectors        *Selectors `bun:"type:jsonb"`
+	ETag             string     `bun:"etag"`
+	LastModified     string
+	ContentHash      string
+	PollInterval     int       `bun:",nullzero,notnull,default:300"`
+	PollJitter       int       `bun:",nullzero,notnull,default:30"`
+	LastRunAt        time.Time `bun:",nullzero"`
+	NextRunAt        time.Time `bun:",nullzero"`
+	FailureCount     int
+	LastError        string
+	CircuitOpenUntil time.Time `bun:",nullzero"`
+	Priority         int
+	TranslateLang    string        `bun:",nullzero"`
+	RetractionPolicy string        `bun:",nullzero,notnull,default:'delete'"`
+	Availability     *Availability `bun:"type:jsonb"`
+	Paywall          *PaywallRules `bun:"type:jsonb"`
+	PaywallPolicy    string        `bun:",nullzero,notnull,default:'publish'"`
+	PaywallChatID    int64         `bun:",nullzero"`
+
+	GUIDRules []*GUIDRule `bun:"rel:has-many,join:id=provider_id"`
+}
+
+// Fields of an item used by GUID rules
+const (
+	GUIDFieldGUID  = "guid"
+	GUIDFieldLink  = "link"
+	GUIDFieldQuery = "query"
+)
+
+// GUIDRule is a rule to extract GUID of an item, pattern has a capture group for the id
+type GUIDRule struct {
+	bun.BaseModel `bun:"table:guid_rules,alias:gr"`
+
+	ID         int `bun:",pk,autoincrement"`
+	ProviderID int
+	Priority   int
+	Field      string
+	Param      string
+	Pattern    string
+	Prefix     string
+}
+
+// Source types of a provider
+const (
+	SourceRSS     = "rss"
+	SourceJSON    = "json"
+	SourceSitemap = "sitemap"
+	SourceHTML    = "html"
+)
+
+// Selectors is a set of CSS selectors for scraping a html listing,
+// a value like "time@datetime" takes the attribute instead of the text
+type Selectors struct {
+	Item            string `json:"item"`
+	Title           string `json:"title"`
+	Link            string `json:"link"`
+	Description     string `json:"description,omitempty"`
+	Image           string `json:"image,omitempty"`
+	Category        string `json:"category,omitempty"`
+	Published       string `json:"published,omitempty"`
+	PublishedLayout string `json:"published_layout,omitempty"`
+}
+
+// Availability is a set of rules detecting that an article is removed from the site
+type Availability struct {
+	StatusCodes       []int    `json:"status_codes,omitempty"`
+	Markers           []string `json:"markers,omitempty"`
+	RedirectToRoot    bool     `json:"redirect_to_root,omitempty"`
+	CanonicalMismatch bool     `json:"canonical_mismatch,omitempty"`
+}
+
+// Policies of a provider for paywalled items
+const (
+	PaywallPublish = "publish"
+	PaywallSkip    = "skip"
+	PaywallRoute   = "route"
+)
+
+// PaywallRules are rules of a provider detecting paywalled articles by the article page
+type PaywallRules struct {
+	AccessibleForFree bool     `json:"accessible_for_free,omitempty"`
+	Classes           []string `json:"classes,omitempty"`
+	Patterns          []string `json:"patterns,omitempty"`
 }
 
 // Category is a category structure
@@ -63,3 +183,271 @@ type BlockedCategory struct {
 	CategoryID int       `bun:",pk"`
 	Category   *Category `bun:"rel:has-one,join:category_id=id"`
 }
+
+// Actions of a filter rule
+const (
+	FilterActionBlock = "block"
+	FilterActionAllow = "allow"
+)
+
+// Fields of an item checked by a filter rule, text is title and description
+const (
+	FilterFieldTitle       = "title"
+	FilterFieldDescription = "description"
+	FilterFieldText        = "text"
+	FilterFieldLink        = "link"
+	FilterFieldCategory    = "category"
+	FilterFieldAuthor      = "author"
+	FilterFieldAny         = "any"
+)
+
+// Match types of a filter rule
+const (
+	FilterMatchSubstring = "substring"
+	FilterMatchWord      = "word"
+	FilterMatchExact     = "exact"
+	FilterMatchRegex     = "regex"
+)
+
+// FilterRule is a rule to block or allow an item, a rule without provider is global
+type FilterRule struct {
+	bun.BaseModel `bun:"table:filter_rules,alias:fr"`
+
+	ID            int `bun:",pk,autoincrement"`
+	ProviderID    int `bun:",nullzero"`
+	ChannelID     int `bun:",nullzero"`
+	Action        string
+	Field         string
+	Match         string
+	Pattern       string
+	CaseSensitive bool
+	Priority      int
+	Hits          int64
+}
+
+// Stages of the item pipeline
+const (
+	StageTerm       = "term"
+	StageFilter     = "filter"
+	StageSimilarity = "similarity"
+	StageMeta       = "meta"
+	StagePaywall    = "paywall"
+	StageImage      = "image"
+	StageHold       = "hold"
+	StagePublish    = "publish"
+	StageEdit       = "edit"
+	StageDelete     = "delete"
+)
+
+// Decisions about an item
+const (
+	DecisionRejected  = "rejected"
+	DecisionPassed    = "passed"
+	DecisionHeld      = "held"
+	DecisionQueued    = "queued"
+	DecisionPublished = "published"
+	DecisionEdited    = "edited"
+	DecisionDeleted   = "deleted"
+	DecisionFailed    = "failed"
+)
+
+// ItemDecision is the last decision about an item at a stage of the pipeline
+type ItemDecision struct {
+	bun.BaseModel `bun:"table:item_decisions,alias:d"`
+
+	ID         int64 `bun:",pk,autoincrement"`
+	EntryID    string
+	Stage      string
+	ProviderID int
+	ChannelID  int `bun:",nullzero"`
+	Link       string
+	Title      string
+	Decision   string
+	Reason     string
+	UpdatedAt  time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Channel *Channel `bun:"rel:has-one,join:channel_id=id"`
+}
+
+// Story is a group of entries from different providers about the same news, the entry is the published one
+type Story struct {
+	bun.BaseModel `bun:"table:stories,alias:s"`
+
+	ID        int `bun:",pk,autoincrement"`
+	EntryID   string
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Sources []*StorySource `bun:"rel:has-many,join:id=story_id"`
+}
+
+// StorySource is an item of another provider attached to a story
+type StorySource struct {
+	bun.BaseModel `bun:"table:story_sources,alias:ss"`
+
+	StoryID    int    `bun:",pk"`
+	EntryID    string `bun:",pk"`
+	ProviderID int
+	Link       string
+	Title      string
+	CreatedAt  time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Provider *Provider `bun:"rel:has-one,join:provider_id=id"`
+}
+
+// PendingItem is an item held before publishing to let duplicates of other providers arrive
+type PendingItem struct {
+	bun.BaseModel `bun:"table:pending_items,alias:pi"`
+
+	EntryID    string `bun:",pk"`
+	ProviderID int
+	Title      string
+	FeedTitle  string
+	Item       *config.FeedItem `bun:"type:jsonb"`
+	ReleaseAt  time.Time
+	CreatedAt  time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Provider *Provider `bun:"rel:has-one,join:provider_id=id"`
+}
+
+// ListingItem is an undated item of a html listing, it is dated by the time it is first seen
+type ListingItem struct {
+	bun.BaseModel `bun:"table:listing_items,alias:li"`
+
+	ProviderID  int    `bun:",pk"`
+	Link        string `bun:",pk"`
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+}
+
+// Operations of outbox messages
+const (
+	OutboxSend    = "send"
+	OutboxEdit    = "edit"
+	OutboxRetract = "retract"
+	OutboxDelete  = "delete"
+)
+
+// OutboxMessage is a pending Telegram operation on the message of an entry in a channel, the operation is
+// enqueued once per idempotency key
+type OutboxMessage struct {
+	bun.BaseModel `bun:"table:outbox,alias:o"`
+
+	ID             int `bun:",pk,autoincrement"`
+	IdempotencyKey string
+	Operation      string
+	EntryID        string
+	ProviderID     int
+	ChannelID      int
+	ChatID         int64            `bun:",nullzero"`
+	MessageID      int              `bun:",nullzero"`
+	ImageURL       string           `bun:",nullzero"`
+	FeedTitle      string           `bun:",nullzero"`
+	Item           *config.FeedItem `bun:"type:jsonb"`
+	Attempts       int
+	LastError      string    `bun:",nullzero"`
+	NextAttemptAt  time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+	SendingAt      time.Time `bun:",nullzero"`
+	SentAt         time.Time `bun:",nullzero"`
+	FailedAt       time.Time `bun:",nullzero"`
+	CreatedAt      time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Provider *Provider `bun:"rel:has-one,join:provider_id=id"`
+	Channel  *Channel  `bun:"rel:has-one,join:channel_id=id"`
+}
+
+// Translation is a cached translation of a text
+type Translation struct {
+	bun.BaseModel `bun:"table:translations,alias:t"`
+
+	Hash       string `bun:",pk"`
+	SourceLang string
+	TargetLang string
+	Text       string
+	CreatedAt  time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// GlossaryTerm is a term translated by the glossary, a term without translation is kept as is,
+// a term without target language is applied for any target language
+type GlossaryTerm struct {
+	bun.BaseModel `bun:"table:glossary_terms,alias:gt"`
+
+	ID          int `bun:",pk,autoincrement"`
+	SourceLang  string
+	TargetLang  string
+	Term        string
+	Translation string
+}
+
+// Names of message templates
+const (
+	TemplateText   = "text"
+	TemplateButton = "button"
+)
+
+// Template is a message template of a provider, a channel or both, a template without provider or channel applies to any
+type Template struct {
+	bun.BaseModel `bun:"table:templates,alias:tpl"`
+
+	ID         int `bun:",pk,autoincrement"`
+	ProviderID int `bun:",nullzero"`
+	ChannelID  int `bun:",nullzero"`
+	Name       string
+	Body       string
+}
+
+// Topic is a canonical topic of provider categories
+type Topic struct {
+	bun.BaseModel `bun:"table:topics,alias:tp"`
+
+	ID      int `bun:",pk,autoincrement"`
+	Name    string
+	Hashtag string
+}
+
+// CategoryToTopic is a map a provider category and a canonical topic
+type CategoryToTopic struct {
+	bun.BaseModel `bun:"table:category_to_topics,alias:ctt"`
+
+	CategoryID int       `bun:",pk"`
+	TopicID    int       `bun:",pk"`
+	Category   *Category `bun:"rel:has-one,join:category_id=id"`
+	Topic      *Topic    `bun:"rel:has-one,join:topic_id=id"`
+}
+
+// EntryRevision is a version of an entry
+type EntryRevision struct {
+	bun.BaseModel `bun:"table:entry_revisions,alias:er"`
+
+	ID          int `bun:",pk,autoincrement"`
+	EntryID     string
+	Link        string
+	Title       string
+	Description string
+	ImageURL    string
+	CreatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+}
+
+// Policies of a provider for messages of articles removed from the site
+const (
+	RetractionDelete = "delete"
+	RetractionStrike = "strike"
+	RetractionKeep   = "keep"
+)
+
+// RetractedEntry is an audit record of a retracted entry
+type RetractedEntry struct {
+	bun.BaseModel `bun:"table:retracted_entries,alias:re"`
+
+	ID          int `bun:",pk,autoincrement"`
+	EntryID     string
+	ProviderID  int
+	MessageID   int
+	Link        string
+	Title       string
+	Description string
+	Policy      string
+	Reason      string
+	CreatedAt   time.Time `bun:",nullzero,notnull,default:current_timestamp"`
+
+	Provider *Provider `bun:"rel:has-one,join:provider_id=id"`
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// SetProviderPaywall set rules of provider detecting paywalled articles, nil rules reset to the default ones
+func SetProviderPaywall(ctx context.Context, providerID int, paywall *PaywallRules) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(&Provider{}).Set("paywall = ?", paywall).Where("id = ?", providerID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set paywall rules of provider '%d': %v", providerID, err)
+	}
+	return nil
+}
+
+// SetProviderPaywallPolicy set policy of provider for paywalled items, chat is required to route them
+func SetProviderPaywallPolicy(ctx context.Context, providerID int, policy string, chatID int64) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	if policy != PaywallPublish && policy != PaywallSkip && policy != PaywallRoute {
+		return fmt.Errorf("failed to set paywall policy of provider '%d': unknown policy '%s'", providerID, policy)
+	}
+	if policy == PaywallRoute && chatID == 0 {
+		return fmt.Errorf("failed to set paywall policy of provider '%d': chat is required to route paywalled items", providerID)
+	}
+	_, err := dbConnect.NewUpdate().Model(&Provider{}).
+		Set("paywall_policy = ?", policy).
+		Set("paywall_chat_id = ?", bun.NullZero(chatID)).
+		Where("id = ?", providerID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set paywall policy of provider '%d': %v", providerID, err)
+	}
+	return nil
+}
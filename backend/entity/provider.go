@@ -0,0 +1,91 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// GetProviders return list providers
+func GetProviders(ctx context.Context) ([]Provider, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var providers []Provider
+	err := dbConnect.NewSelect().Model(&providers).Relation("GUIDRules", func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.Order("priority DESC", "id")
+	}).Order("p.id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of providers: %v", err)
+	}
+	return providers, nil
+}
+
+// ScheduleProvider mark provider as run at now and plan the next run by its interval and jitter
+func ScheduleProvider(ctx context.Context, provider *Provider, now time.Time) error {
+	interval := time.Duration(provider.PollInterval) * time.Second
+	if interval <= 0 {
+		interval = config.TimeoutBetweenLoops
+	}
+	if provider.PollJitter > 0 {
+		interval += time.Duration(rand.Int64N(int64(provider.PollJitter) * int64(time.Second))) //nolint:gosec
+	}
+	provider.LastRunAt = now
+	provider.NextRunAt = now.Add(interval)
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(provider).Column("last_run_at", "next_run_at").WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to schedule provider '%d': %v", provider.ID, err)
+	}
+	return nil
+}
+
+// RecordProviderFailure store failure of provider and plan a retry with backoff,
+// after too many failures in a row the circuit breaker is opened and provider is paused
+func RecordProviderFailure(ctx context.Context, provider *Provider, cause error, now time.Time) (bool, error) {
+	provider.FailureCount++
+	provider.LastError = cause.Error()
+	backoff := config.RetryBackoffBase << min(provider.FailureCount-1, 16)
+	if backoff > config.RetryBackoffMax {
+		backoff = config.RetryBackoffMax
+	}
+	provider.NextRunAt = now.Add(backoff)
+	opened := false
+	if provider.FailureCount >= config.CircuitBreakerThreshold {
+		opened = provider.CircuitOpenUntil.IsZero()
+		provider.CircuitOpenUntil = now.Add(config.CircuitBreakerTimeout)
+		provider.NextRunAt = provider.CircuitOpenUntil
+	}
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(provider).Column("failure_count", "last_error", "next_run_at", "circuit_open_until").WherePK().Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to record failure of provider '%d': %v", provider.ID, err)
+	}
+	return opened, nil
+}
+
+// RecordProviderSuccess reset failures of provider and close its circuit breaker
+func RecordProviderSuccess(ctx context.Context, provider *Provider) error {
+	provider.FailureCount = 0
+	provider.LastError = ""
+	provider.CircuitOpenUntil = time.Time{}
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(provider).Column("failure_count", "last_error", "circuit_open_until").WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to record success of provider '%d': %v", provider.ID, err)
+	}
+	return nil
+}
+
+// SetProviderPriority set priority of provider when choosing which duplicate to publish
+func SetProviderPriority(ctx context.Context, providerID, priority int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(&Provider{}).Set("priority = ?", priority).Where("id = ?", providerID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set priority of provider '%d': %v", providerID, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// SetProviderRetractionPolicy set policy of provider for messages of removed articles
+func SetProviderRetractionPolicy(ctx context.Context, providerID int, policy string) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	if policy != RetractionDelete && policy != RetractionStrike && policy != RetractionKeep {
+		return fmt.Errorf("failed to set retraction policy of provider '%d': unknown policy '%s'", providerID, policy)
+	}
+	_, err := dbConnect.NewUpdate().Model(&Provider{}).Set("retraction_policy = ?", policy).Where("id = ?", providerID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set retraction policy of provider '%d': %v", providerID, err)
+	}
+	return nil
+}
+
+// AddRetractedEntry add audit record of retracted entry and mark entry retracted,
+// the message of the first channel of entry is recorded
+func AddRetractedEntry(ctx context.Context, entry Entry, policy, reason string) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	messageID := 0
+	if len(entry.Messages) > 0 {
+		messageID = entry.Messages[0].MessageID
+	}
+	_, err := dbConnect.NewInsert().Model(&RetractedEntry{
+		EntryID:     entry.ID,
+		ProviderID:  entry.ProviderID,
+		MessageID:   messageID,
+		Link:        entry.Link,
+		Title:       entry.Title,
+		Description: entry.Description,
+		Policy:      policy,
+		Reason:      reason,
+	}).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add retracted entry '%s': %v", entry.ID, err)
+	}
+	_, err = dbConnect.NewUpdate().Model(&Entry{}).Set("retracted_at = ?", time.Now()).Where("id = ?", entry.ID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add retracted entry '%s': %v", entry.ID, err)
+	}
+	return nil
+}
+
+// GetRetractedEntries return the last retracted entries
+func GetRetractedEntries(ctx context.Context, limit int) ([]RetractedEntry, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var entries []RetractedEntry
+	err := dbConnect.NewSelect().Model(&entries).Relation("Provider").Order("re.created_at DESC").Limit(limit).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retracted entries: %v", err)
+	}
+	return entries, nil
+}
+
+// SetProviderAvailability set rules of provider detecting removed articles, nil rules reset to the default ones
+func SetProviderAvailability(ctx context.Context, providerID int, availability *Availability) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(&Provider{}).Set("availability = ?", availability).Where("id = ?", providerID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set availability of provider '%d': %v", providerID, err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// GetEntryRevisions return revisions of entry from the oldest
+func GetEntryRevisions(ctx context.Context, entryID string) ([]EntryRevision, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var revisions []EntryRevision
+	err := dbConnect.NewSelect().Model(&revisions).Where("entry_id = ?", entryID).Order("created_at", "id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get revisions of entry '%s': %v", entryID, err)
+	}
+	return revisions, nil
+}
+
+// AddEntryRevision add revision of entry if it differs from the last revision
+func AddEntryRevision(ctx context.Context, revision *EntryRevision) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	exists, err := dbConnect.NewSelect().Model(&EntryRevision{}).
+		Where("id = (SELECT max(id) FROM entry_revisions WHERE entry_id = ?)", revision.EntryID).
+		Where("link = ? AND title = ? AND description = ? AND image_url = ?", revision.Link, revision.Title, revision.Description, revision.ImageURL).
+		Exists(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add revision of entry '%s': %v", revision.EntryID, err)
+	}
+	if exists {
+		return nil
+	}
+	_, err = dbConnect.NewInsert().Model(revision).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add revision of entry '%s': %v", revision.EntryID, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+// GetTemplate return the most specific template of provider and channel, nil is returned if there is no template
+func GetTemplate(ctx context.Context, providerID int, channelID int, name string) (*Template, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var template Template
+	err := dbConnect.NewSelect().Model(&template).
+		Where("name = ?", name).
+		Where("provider_id IS NULL OR provider_id = ?", providerID).
+		Where("channel_id IS NULL OR channel_id = ?", channelID).
+		OrderExpr("provider_id IS NULL, channel_id IS NULL").
+		Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template '%s' of provider %d and channel %d: %v", name, providerID, channelID, err)
+	}
+	return &template, nil
+}
+
+// GetListTemplates return list templates
+func GetListTemplates(ctx context.Context) ([]Template, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var templates []Template
+	err := dbConnect.NewSelect().Model(&templates).Order("id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of templates: %v", err)
+	}
+	return templates, nil
+}
+
+// SetTemplate add template or replace the template of the same provider, channel and name
+func SetTemplate(ctx context.Context, template *Template) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewInsert().Model(template).
+		On("CONFLICT (COALESCE(provider_id, 0), COALESCE(channel_id, 0), name) DO UPDATE").
+		Set("body = EXCLUDED.body").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set template '%s': %v", template.Name, err)
+	}
+	return nil
+}
+
+// DeleteTemplate delete template
+func DeleteTemplate(ctx context.Context, templateID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(&Template{}).Where("id = ?", templateID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete template %d: %v", templateID, err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"estonia-news/config"
+
+	"github.com/uptrace/bun"
+)
+
+var hashtagRegexp = regexp.MustCompile(`^#[\p{L}\p{N}_]+$`)
+
+// GetTopics return list topics
+func GetTopics(ctx context.Context) ([]Topic, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var topics []Topic
+	err := dbConnect.NewSelect().Model(&topics).Order("id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of topics: %v", err)
+	}
+	return topics, nil
+}
+
+// AddTopic add topic, the hashtag of an existing topic is replaced
+func AddTopic(ctx context.Context, name, hashtag string) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	if !strings.HasPrefix(hashtag, "#") {
+		hashtag = "#" + hashtag
+	}
+	if name == "" || !hashtagRegexp.MatchString(hashtag) {
+		return fmt.Errorf("failed to add topic '%s': invalid name or hashtag '%s'", name, hashtag)
+	}
+	_, err := dbConnect.NewInsert().Model(&Topic{Name: name, Hashtag: hashtag}).
+		On("CONFLICT (name) DO UPDATE").Set("hashtag = EXCLUDED.hashtag").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add topic '%s': %v", name, err)
+	}
+	return nil
+}
+
+// DeleteTopic delete topic
+func DeleteTopic(ctx context.Context, topicID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(&Topic{}).Where("id = ?", topicID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete topic %d: %v", topicID, err)
+	}
+	return nil
+}
+
+// MapCategoryToTopic add category to topic
+func MapCategoryToTopic(ctx context.Context, categoryID, topicID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewInsert().Model(&CategoryToTopic{
+		CategoryID: categoryID,
+		TopicID:    topicID,
+	}).Ignore().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to map category %d to topic %d: %v", categoryID, topicID, err)
+	}
+	return nil
+}
+
+// UnmapCategoryFromTopic delete category from topic
+func UnmapCategoryFromTopic(ctx context.Context, categoryID, topicID int) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(&CategoryToTopic{}).Where("category_id = ? AND topic_id = ?", categoryID, topicID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to unmap category %d from topic %d: %v", categoryID, topicID, err)
+	}
+	return nil
+}
+
+// GetTopicsByCategories return distinct topics of categories ordered by id
+func GetTopicsByCategories(ctx context.Context, categoryIDs []int) ([]Topic, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var topics []Topic
+	if len(categoryIDs) == 0 {
+		return topics, nil
+	}
+	err := dbConnect.NewSelect().Model(&topics).
+		Where("id IN (SELECT topic_id FROM category_to_topics WHERE category_id IN (?))", bun.In(categoryIDs)).
+		Order("id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topics of categories: %v", err)
+	}
+	return topics, nil
+}
+
+// GetCategoriesByProvider return categories of provider with their topics
+func GetCategoriesByProvider(ctx context.Context, providerID int) ([]Category, map[int][]Topic, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var categories []Category
+	err := dbConnect.NewSelect().Model(&categories).Where("provider_id = ?", providerID).Order("name").Scan(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get categories of provider %d: %v", providerID, err)
+	}
+	var mappings []CategoryToTopic
+	err = dbConnect.NewSelect().Model(&mappings).Relation("Topic").Relation("Category").
+		Where("category.provider_id = ?", providerID).Scan(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get topics of provider %d: %v", providerID, err)
+	}
+	topics := make(map[int][]Topic)
+	for _, mapping := range mappings {
+		topics[mapping.CategoryID] = append(topics[mapping.CategoryID], *mapping.Topic)
+	}
+	return categories, topics, nil
+}
@@ -5,10 +5,10 @@ go 1.24.0
 toolchain go1.24.7
 
 require (
+	github.com/PuerkitoBio/goquery v1.10.3
 	github.com/go-pkgz/lgr v0.12.1
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
 	github.com/joho/godotenv v1.5.1
-	github.com/lafin/http v0.0.5
 	github.com/lib/pq v1.10.5
 	github.com/mmcdole/gofeed v1.3.0
 	github.com/prometheus/client_golang v1.23.2
@@ -21,7 +21,6 @@ require (
 )
 
 require (
-	github.com/PuerkitoBio/goquery v1.10.3 // indirect
 	github.com/andybalholm/cascadia v1.3.3 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
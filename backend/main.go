@@ -1,11 +1,10 @@
 package main
 
 import (
+	"cmp"
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
-	"net/url"
 	"os"
 	"sort"
 	"strconv"
@@ -27,80 +26,136 @@ import (
 	"github.com/uptrace/bun"
 )
 
-func hasChanges(item *config.FeedItem, entry entity.Entry) bool {
-	return entry.Title != item.Title || entry.Description != item.Description || entry.Link != item.Link || entry.ImageURL != item.ImageURL || entry.Paywall != item.Paywall
-}
-
+// checkRecord put publishing of item to the channels without its message and edit of its messages on changes to the outbox
 func checkRecord(ctx context.Context, item *config.FeedItem) error {
-	var entry entity.Entry
-	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
-	err := dbConnect.NewSelect().Model(&entry).Where("id = ?", item.GUID).Limit(1).Scan(ctx)
-	if !errors.Is(err, sql.ErrNoRows) {
-		if hasChanges(item, entry) {
-			if err := editMessage(ctx, item, entry); err != nil {
-				return err
+	entry, err := service.GetRecord(ctx, item.GUID)
+	if err != nil {
+		return err
+	}
+	if entry != nil && !entry.RetractedAt.IsZero() {
+		return nil
+	}
+	isNew := entry == nil
+	var changes []string
+	// a throttled edit is queued for the end of the edit interval, the later changes refresh the queued edit
+	editAt := time.Now()
+	if isNew {
+		entry = &entity.Entry{ID: item.GUID}
+	} else {
+		changes = service.GetSignificantChanges(item, *entry)
+		if entry.EditedAt.After(editAt) {
+			editAt = entry.EditedAt
+		} else if nextEditAt := entry.EditedAt.Add(config.MinEditInterval); editAt.Before(nextEditAt) {
+			editAt = nextEditAt
+		}
+	}
+	var publishTargets []*service.ChannelTarget
+	var editMessages []*entity.EntryMessage
+	// channels sharing a chat, e.g. the paywall chat of the provider, get the item once
+	chats := map[int64]bool{}
+	for _, message := range entry.Messages {
+		chats[message.ChatID] = true
+	}
+	for _, target := range ctx.Value(config.CtxChannelsKey).([]*service.ChannelTarget) {
+		message := service.GetRecordMessage(entry, target.Channel.ID)
+		switch {
+		case message == nil && isValidItemForChannel(ctx, target, item):
+			chatID := service.GetChatID(channelContext(ctx, target.Channel), item.Paywall)
+			if chats[chatID] {
+				service.Trace(channelContext(ctx, target.Channel), item, entity.StagePublish, entity.DecisionRejected, fmt.Sprintf("chat %d of channel %s has the item already", chatID, target.Channel.Name))
+				continue
 			}
+			chats[chatID] = true
+			publishTargets = append(publishTargets, target)
+		case message != nil && len(changes) > 0:
+			message.Channel = target.Channel
+			editMessages = append(editMessages, message)
 		}
+	}
+	if len(publishTargets) == 0 && len(editMessages) == 0 {
 		return nil
 	}
-	if err := newMessage(ctx, item); err != nil {
-		return err
+	imageFileID := ""
+	if entry.ImageURL == item.ImageURL {
+		imageFileID = entry.ImageFileID
 	}
-	time.Sleep(config.TimeoutBetweenMessages)
-	return nil
-}
-
-func editMessage(ctx context.Context, item *config.FeedItem, entry entity.Entry) error {
-	misc.Info(fmt.Sprintf("send edit message '%s'", entry.ID))
-	msg, err := service.Edit(ctx, item, entry)
-	if err != nil {
-		if strings.Contains(err.Error(), "message to edit not found") {
-			if err = service.DeleteRecord(ctx, entry); err != nil {
-				misc.Error("delete_record", fmt.Sprintf("delete record '%s'", entry.ID), err)
-				return fmt.Errorf("failed to edit message for record '%s': %v", entry.ID, err)
-			}
-			time.Sleep(config.TimeoutBetweenMessages)
+	if isNew {
+		if err := service.UpsertRecord(ctx, item, imageFileID); err != nil {
+			return err
 		}
-		return fmt.Errorf("failed to edit message for record '%s': %v", entry.ID, err)
 	}
-	_, err = sendMessage(ctx, msg)
-	if err != nil {
-		return fmt.Errorf("failed to edit message for record '%s': %v", entry.ID, err)
+	for _, message := range editMessages {
+		if err := service.EnqueueEdit(ctx, item, *entry, message, strconv.FormatInt(editAt.UnixNano(), 10), editAt); err != nil {
+			return err
+		}
+		if editAt.After(time.Now()) {
+			service.Trace(channelContext(ctx, message.Channel), item, entity.StageEdit, entity.DecisionHeld, fmt.Sprintf("edit of message %d in channel %s is held until %s, changed %s", message.MessageID, message.Channel.Name, editAt.Format(time.DateTime), strings.Join(changes, ", ")))
+			continue
+		}
+		service.Trace(channelContext(ctx, message.Channel), item, entity.StageEdit, entity.DecisionQueued, fmt.Sprintf("edit of message %d in channel %s is queued, changed %s", message.MessageID, message.Channel.Name, strings.Join(changes, ", ")))
 	}
-	err = service.UpsertRecord(ctx, item, entry.MessageID)
-	if err != nil {
-		return fmt.Errorf("failed to edit message for record '%s': %v", entry.ID, err)
+	for _, target := range publishTargets {
+		if err := service.EnqueueSend(ctx, item, target.Channel.ID); err != nil {
+			return err
+		}
+		service.Trace(channelContext(ctx, target.Channel), item, entity.StagePublish, entity.DecisionQueued, fmt.Sprintf("message to channel %s is queued", target.Channel.Name))
+	}
+	if len(changes) > 0 {
+		if err := service.UpsertRecord(ctx, item, imageFileID); err != nil {
+			return err
+		}
+	}
+	if len(editMessages) > 0 {
+		return service.MarkRecordEdited(ctx, entry.ID, editAt)
 	}
 	return nil
 }
 
-func newMessage(ctx context.Context, item *config.FeedItem) error {
-	misc.Info(fmt.Sprintf("send message '%s'", item.GUID))
-	msg, err := service.Add(ctx, item)
-	if err != nil {
-		return fmt.Errorf("failed to add message for record '%s': %v", item.GUID, err)
+// editMessage perform edit of the message of entry in a channel, a new message is queued if it can't be edited
+func editMessage(ctx context.Context, item *config.FeedItem, entry entity.Entry, message *entity.EntryMessage) (*tgbotapi.Message, error) {
+	misc.Info(fmt.Sprintf("send edit message '%s' to chat %d", entry.ID, message.ChatID))
+	msg, err := service.Edit(ctx, item, entry, message)
+	if errors.Is(err, service.ErrRepostRequired) {
+		misc.Info(fmt.Sprintf("repost message '%s' to chat %d: %v", entry.ID, message.ChatID, err))
+		return nil, service.EnqueueRepost(ctx, item, message)
 	}
-	sendedMsg, err := sendMessage(ctx, msg)
 	if err != nil {
-		return fmt.Errorf("failed to add message for record '%s': %v", item.GUID, err)
-	}
-	if sendedMsg.MessageID == 0 {
-		err = errors.New("empty MessageID")
-		misc.Error("add_record", fmt.Sprintf("add record '%s'", item.GUID), err)
-		return fmt.Errorf("failed to add message for record '%s': %v", item.GUID, err)
+		return nil, fmt.Errorf("failed to edit message for record '%s': %v", entry.ID, err)
 	}
-	err = service.UpsertRecord(ctx, item, sendedMsg.MessageID)
+	return sendMessage(ctx, msg)
+}
+
+// newMessage perform send of a message of item to the channel of outbox message, a message accepted by Telegram
+// is never sent again even if it can't be recorded
+func newMessage(ctx context.Context, item *config.FeedItem, message *entity.OutboxMessage) (*tgbotapi.Message, error) {
+	channel := message.Channel
+	misc.Info(fmt.Sprintf("send message '%s' to channel %s", item.GUID, channel.Name))
+	msg, err := service.Add(ctx, item)
 	if err != nil {
-		return fmt.Errorf("failed to add message for record '%s': %v", item.GUID, err)
+		return nil, fmt.Errorf("failed to add message for record '%s': %v", item.GUID, err)
 	}
-	return nil
+	return service.DeliverOutboxSend(ctx, message, func() (*tgbotapi.Message, error) {
+		sendedMsg, err := sendMessage(ctx, msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add message for record '%s': %v", item.GUID, err)
+		}
+		return sendedMsg, nil
+	}, func(sendedMsg *tgbotapi.Message) error {
+		return service.UpsertRecordMessage(ctx, &entity.EntryMessage{
+			EntryID:     item.GUID,
+			ChannelID:   channel.ID,
+			ChatID:      service.GetChatID(ctx, item.Paywall),
+			MessageID:   sendedMsg.MessageID,
+			MessageType: service.GetMessageType(sendedMsg),
+		})
+	})
 }
 
 func sendMessage(ctx context.Context, msg tgbotapi.Chattable) (*tgbotapi.Message, error) {
 	bot := ctx.Value(config.CtxBotKey).(*tgbotapi.BotAPI)
 	sendedMsg, err := bot.Send(msg)
 	if err != nil {
-		if funk.Contains([]string{"message is not modified", "there is no caption in the message to edit"}, func(item string) bool {
+		if funk.Contains([]string{"message is not modified"}, func(item string) bool {
 			return strings.Contains(err.Error(), item)
 		}) {
 			misc.Error("send_message", "send message", err)
@@ -111,101 +166,342 @@ func sendMessage(ctx context.Context, msg tgbotapi.Chattable) (*tgbotapi.Message
 	return &sendedMsg, nil
 }
 
-func getProviders(ctx context.Context) []entity.Provider {
-	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
-	var providers []entity.Provider
-	err := dbConnect.NewSelect().Model(&providers).Scan(ctx)
-	if err != nil {
-		misc.Fatal("get_providers", "get providers", err)
-	}
-	return providers
-}
-
 func deleteDeletedEntries(ctx context.Context, items []*config.FeedItem) error {
 	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
 	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
 	var entries []entity.Entry
-	err := dbConnect.NewSelect().Model(&entries).Where(fmt.Sprintf("published_at > NOW() - INTERVAL '%d hours' AND provider_id = %d", config.TimeShift/time.Hour, provider.ID)).Scan(ctx)
+	err := dbConnect.NewSelect().Model(&entries).Relation("Messages").
+		Where(fmt.Sprintf("published_at > NOW() - INTERVAL '%d hours' AND provider_id = %d AND retracted_at IS NULL", config.TimeShift/time.Hour, provider.ID)).
+		Where("EXISTS (SELECT 1 FROM entry_messages AS em WHERE em.entry_id = e.id)").Scan(ctx)
 	if err != nil {
-		misc.Fatal("query_entries", "query entries", err)
+		return fmt.Errorf("failed to query entries: %v", err)
 	}
 	for _, entry := range entries {
 		foundEntry := funk.Contains(items, func(item *config.FeedItem) bool {
 			return entry.ID == item.GUID
 		})
-		linkUnavailable := service.IsLinkUnavailable(entry.Link)
-		if !foundEntry && linkUnavailable {
-			if err := service.Delete(ctx, entry); err != nil {
-				misc.Error("delete_message", fmt.Sprintf("delete message '%s'", entry.ID), err)
-				if !strings.Contains(err.Error(), "message to delete not found") {
-					return fmt.Errorf("failed to delete message for record '%s': %v", entry.ID, err)
+		if foundEntry {
+			continue
+		}
+		unavailable, cause := service.IsLinkUnavailable(provider, entry.Link)
+		if !unavailable {
+			continue
+		}
+		item := &config.FeedItem{GUID: entry.ID, Link: entry.Link, Title: entry.Title}
+		reason := fmt.Sprintf("removed from the feed and the link is unavailable: %s", cause)
+		policy := provider.RetractionPolicy
+		if policy == "" {
+			policy = entity.RetractionDelete
+		}
+		switch policy {
+		case entity.RetractionKeep:
+			reason += ", message is kept"
+		case entity.RetractionStrike:
+			for _, message := range entry.Messages {
+				if err := service.EnqueueRetract(ctx, entry, message); err != nil {
+					return err
+				}
+			}
+			reason += ", strike through of the message is queued"
+		default:
+			for _, message := range entry.Messages {
+				if err := service.EnqueueDelete(ctx, item, message); err != nil {
+					return err
 				}
 			}
 			if err := service.DeleteRecord(ctx, entry); err != nil {
 				misc.Error("delete_record", fmt.Sprintf("delete record '%s'", entry.ID), err)
 				return fmt.Errorf("failed to delete message for record '%s': %v", entry.ID, err)
 			}
-			time.Sleep(config.TimeoutBetweenMessages)
 		}
+		if err := entity.AddRetractedEntry(ctx, entry, policy, reason); err != nil {
+			return err
+		}
+		service.Trace(ctx, item, entity.StageDelete, entity.DecisionDeleted, reason)
 	}
 	return nil
 }
 
-func isValidItemByContent(blockedCategories, blockedWords, blockedDomains []string, item *config.FeedItem) bool {
-	if funk.Contains(blockedDomains, func(domain string) bool {
-		return strings.Contains(item.Link, domain)
-	}) {
-		return false
+// retractMessage perform edit of the message of entry in a channel to mark the article withdrawn
+func retractMessage(ctx context.Context, entry entity.Entry, message *entity.EntryMessage) error {
+	misc.Info(fmt.Sprintf("send retract message '%s' to chat %d", entry.ID, message.ChatID))
+	msg, err := service.Retract(ctx, entry, message)
+	if err != nil {
+		return fmt.Errorf("failed to retract message for record '%s': %v", entry.ID, err)
 	}
-	if len(funk.IntersectString(blockedCategories, item.Categories)) > 0 {
-		return false
+	if _, err = sendMessage(ctx, msg); err != nil {
+		return fmt.Errorf("failed to retract message for record '%s': %v", entry.ID, err)
 	}
-	foundBlockedWords := funk.FilterString(blockedWords, func(word string) bool {
-		return strings.Contains(item.Title, word) || strings.Contains(item.Description, word)
-	})
-	return len(foundBlockedWords) == 0
+	return nil
+}
+
+// reconcileOutbox perform check of the sends of the outbox left in flight, a send which reached the record of
+// the entry is done, the others are given up for the admin to check the chat instead of a possible duplicate
+func reconcileOutbox(ctx context.Context) {
+	messages, err := service.GetInterruptedOutboxMessages(ctx, time.Now())
+	if err != nil {
+		misc.Error("get_outbox", "get interrupted outbox messages", err)
+		return
+	}
+	for _, message := range messages {
+		messageCtx := channelContext(providerContext(ctx, message.Provider, message.FeedTitle), message.Channel)
+		entry, err := service.GetRecord(ctx, message.EntryID)
+		if err != nil {
+			misc.Error("reconcile_outbox", fmt.Sprintf("get record '%s'", message.EntryID), err)
+			continue
+		}
+		var current *entity.EntryMessage
+		if entry != nil {
+			current = service.GetRecordMessage(entry, message.ChannelID)
+		}
+		if current != nil && current.MessageID != message.MessageID {
+			if err := service.MarkOutboxSent(ctx, message); err != nil {
+				misc.Error("mark_outbox_sent", fmt.Sprintf("mark outbox message %d sent", message.ID), err)
+			}
+			continue
+		}
+		if err := service.MarkOutboxInterrupted(ctx, message, "sending is interrupted, the message may be in the chat", time.Now()); err != nil {
+			misc.Error("mark_outbox_failure", fmt.Sprintf("mark outbox message %d interrupted", message.ID), err)
+			continue
+		}
+		service.Trace(messageCtx, message.Item, outboxStage(message.Operation), entity.DecisionFailed, fmt.Sprintf("channel %s: %s", message.Channel.Name, message.LastError))
+	}
+}
+
+// sendOutbox perform the due operations of the outbox claimed by the worker, a failed operation is retried later
+func sendOutbox(ctx context.Context) {
+	messages, err := service.ClaimDueOutboxMessages(ctx, time.Now())
+	if err != nil {
+		misc.Error("get_outbox", "get outbox messages", err)
+		return
+	}
+	for _, message := range messages {
+		messageCtx := channelContext(providerContext(ctx, message.Provider, message.FeedTitle), message.Channel)
+		err := sendOutboxMessage(messageCtx, message)
+		switch {
+		case errors.Is(err, service.ErrSentNotRecorded):
+			misc.Error("send_outbox", fmt.Sprintf("send %s of record '%s'", message.Operation, message.EntryID), err)
+			service.Trace(messageCtx, message.Item, outboxStage(message.Operation), entity.DecisionFailed, fmt.Sprintf("channel %s: %v", message.Channel.Name, err))
+		case err != nil:
+			misc.Error("send_outbox", fmt.Sprintf("send %s of record '%s'", message.Operation, message.EntryID), err)
+			failed, err := service.MarkOutboxFailure(ctx, message, err, time.Now())
+			if err != nil {
+				misc.Error("mark_outbox_failure", fmt.Sprintf("record failure of outbox message %d", message.ID), err)
+			}
+			if failed {
+				service.Trace(messageCtx, message.Item, outboxStage(message.Operation), entity.DecisionFailed, fmt.Sprintf("channel %s: given up after %d attempts: %s", message.Channel.Name, message.Attempts, message.LastError))
+			}
+		default:
+			if err := service.MarkOutboxSent(ctx, message); err != nil {
+				misc.Error("mark_outbox_sent", fmt.Sprintf("mark outbox message %d sent", message.ID), err)
+			}
+		}
+		time.Sleep(config.TimeoutBetweenMessages)
+	}
+}
+
+// outboxStage return stage of the item pipeline of outbox operation
+func outboxStage(operation string) string {
+	switch operation {
+	case entity.OutboxSend:
+		return entity.StagePublish
+	case entity.OutboxEdit, entity.OutboxRetract:
+		return entity.StageEdit
+	default:
+		return entity.StageDelete
+	}
+}
+
+// sendOutboxMessage perform operation of outbox message, an operation made needless by the later ones is skipped
+func sendOutboxMessage(ctx context.Context, message *entity.OutboxMessage) error {
+	item := message.Item
+	if message.Operation == entity.OutboxDelete {
+		err := service.Delete(ctx, entity.Entry{ID: message.EntryID}, &entity.EntryMessage{ChatID: message.ChatID, MessageID: message.MessageID})
+		if err != nil && !strings.Contains(err.Error(), "message to delete not found") {
+			return err
+		}
+		service.Trace(ctx, item, entity.StageDelete, entity.DecisionDeleted, fmt.Sprintf("message %d in channel %s is deleted", message.MessageID, message.Channel.Name))
+		return nil
+	}
+	entry, err := service.GetRecord(ctx, message.EntryID)
+	if err != nil || entry == nil {
+		return err
+	}
+	current := service.GetRecordMessage(entry, message.ChannelID)
+	switch message.Operation {
+	case entity.OutboxSend:
+		if !entry.RetractedAt.IsZero() || current != nil && current.MessageID != message.MessageID {
+			return nil
+		}
+		sendedMsg, err := newMessage(ctx, item, message)
+		if err != nil {
+			return err
+		}
+		if fileID := service.GetPhotoFileID(sendedMsg); fileID != "" {
+			if err := service.SetRecordImageFileID(ctx, item, fileID); err != nil {
+				misc.Error("set_image_file_id", fmt.Sprintf("set image file id of record '%s'", item.GUID), err)
+			}
+		}
+		service.Trace(ctx, item, entity.StagePublish, entity.DecisionPublished, fmt.Sprintf("message %d is sent to channel %s", sendedMsg.MessageID, message.Channel.Name))
+	case entity.OutboxEdit:
+		if current == nil || current.MessageID != message.MessageID {
+			return nil
+		}
+		entry.ImageURL = message.ImageURL
+		sendedMsg, err := editMessage(ctx, item, *entry, current)
+		if err != nil {
+			if strings.Contains(err.Error(), "message to edit not found") {
+				misc.Error("edit_message", fmt.Sprintf("edit message '%s'", entry.ID), err)
+				return service.DeleteRecordMessage(ctx, current)
+			}
+			return err
+		}
+		if sendedMsg == nil {
+			return nil
+		}
+		if fileID := service.GetPhotoFileID(sendedMsg); fileID != "" {
+			if err := service.SetRecordImageFileID(ctx, item, fileID); err != nil {
+				misc.Error("set_image_file_id", fmt.Sprintf("set image file id of record '%s'", item.GUID), err)
+			}
+		}
+		service.Trace(ctx, item, entity.StageEdit, entity.DecisionEdited, fmt.Sprintf("message %d in channel %s is edited", current.MessageID, message.Channel.Name))
+	case entity.OutboxRetract:
+		if current == nil || current.MessageID != message.MessageID {
+			return nil
+		}
+		if err := retractMessage(ctx, *entry, current); err != nil {
+			return err
+		}
+		service.Trace(ctx, item, entity.StageDelete, entity.DecisionEdited, fmt.Sprintf("message %d in channel %s is struck through", current.MessageID, message.Channel.Name))
+	}
+	return nil
+}
+
+func isValidItemForChannel(ctx context.Context, target *service.ChannelTarget, item *config.FeedItem) bool {
+	return target.Filter.Decide(channelContext(ctx, target.Channel), item, fmt.Sprintf(" in channel %s", target.Channel.Name))
 }
 
-func isValidItemByTerm(item *config.FeedItem) bool {
+func isValidItemByContent(ctx context.Context, filter *service.Filter, item *config.FeedItem) bool {
+	return filter.Decide(ctx, item, "")
+}
+
+func getAuthor(item *gofeed.Item) string {
+	names := funk.Map(item.Authors, func(author *gofeed.Person) string {
+		return author.Name
+	}).([]string)
+	return strings.Join(names, ", ")
+}
+
+func isValidItemByTerm(ctx context.Context, item *config.FeedItem) bool {
 	pubDate, _ := time.Parse(time.RFC1123Z, item.Published)
-	return pubDate.Add(config.TimeShift).After(time.Now())
+	if pubDate.Add(config.TimeShift).After(time.Now()) {
+		return true
+	}
+	service.Trace(ctx, item, entity.StageTerm, entity.DecisionRejected, fmt.Sprintf("published at '%s' is older than %s", item.Published, config.TimeShift))
+	return false
 }
 
-func findSimilarRecord(ctx context.Context, item *config.FeedItem) (bool, error) {
+// updateStoryMessage put edit of the published entry of a story listing the other sources to the outbox
+func updateStoryMessage(ctx context.Context, entry *entity.Entry) error {
+	var categories []entity.EntryToCategory
 	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
-	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
-	var entry entity.Entry
-	exists, err := dbConnect.NewSelect().Model(&entry).Where("updated_at > NOW() - INTERVAL '1 day' AND provider_id != ? AND similarity(?,title) > 0.3", provider.ID, item.Title).Exists(ctx)
+	err := dbConnect.NewSelect().Model(&categories).Relation("Category").Where("entry_id = ?", entry.ID).Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to update story message of '%s': %v", entry.ID, err)
+	}
+	var messages []*entity.EntryMessage
+	err = dbConnect.NewSelect().Model(&messages).Where("entry_id = ?", entry.ID).Scan(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to check for similar record: %v", err)
+		return fmt.Errorf("failed to update story message of '%s': %v", entry.ID, err)
+	}
+	ctx = providerContext(ctx, entry.Provider, entry.Provider.Name)
+	item := &config.FeedItem{
+		GUID:        entry.ID,
+		Link:        entry.Link,
+		Title:       entry.Title,
+		ImageURL:    entry.ImageURL,
+		Description: entry.Description,
+		Published:   entry.PublishedAt.Format(time.RFC1123Z),
+		Paywall:     entry.Paywall,
+		Categories: funk.Map(categories, func(category entity.EntryToCategory) string {
+			return category.Category.Name
+		}).([]string),
+		CategoriesIDs: funk.Map(categories, func(category entity.EntryToCategory) int {
+			return category.CategoryID
+		}).([]int),
 	}
-	return exists, nil
+	sources, err := service.GetStorySources(ctx, entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update story message of '%s': %v", entry.ID, err)
+	}
+	for _, message := range messages {
+		if err := service.EnqueueEdit(ctx, item, *entry, message, fmt.Sprintf("sources-%d", len(sources)), time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func addMissingEntries(ctx context.Context, items []*config.FeedItem) error {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
 	for _, item := range items {
-		found, err := findSimilarRecord(ctx, item)
+		attached, err := service.IsStorySource(ctx, item)
+		if err != nil {
+			misc.Error("check_story_source", fmt.Sprintf("check story source '%s'", item.GUID), err)
+			return err
+		}
+		if attached {
+			continue
+		}
+		similar, err := service.FindSimilarEntry(ctx, item)
 		if err != nil {
 			misc.Error("find_similar_record", fmt.Sprintf("find similar record '%s'", item.GUID), err)
 			return err
 		}
-		if found {
+		if similar != nil {
+			added, err := service.AddStorySource(ctx, similar, item)
+			if err != nil {
+				misc.Error("add_story_source", fmt.Sprintf("add story source '%s'", item.GUID), err)
+				return err
+			}
+			service.Trace(ctx, item, entity.StageSimilarity, entity.DecisionRejected, fmt.Sprintf("added to story of '%s' %s", similar.ID, similar.Title))
+			if added {
+				if err := updateStoryMessage(ctx, similar); err != nil {
+					misc.Error("update_story_message", fmt.Sprintf("update story message '%s'", similar.ID), err)
+				}
+			}
 			continue
 		}
-		meta, err := service.GetMeta(item.Link)
+		meta, err := service.GetMeta(item.Link, provider.Paywall)
 		if err != nil {
 			misc.Error("get_meta", "get meta", err)
+			service.Trace(ctx, item, entity.StageMeta, entity.DecisionRejected, err.Error())
 			continue
 		}
-		_, err = url.ParseRequestURI(meta.ImageURL)
-		if err != nil {
-			misc.Error("parse_image_url", "parse image url", err)
+		service.ApplyMeta(item, meta)
+		if len(item.CategoriesIDs) == 0 && len(item.Categories) > 0 {
+			if err := service.AddItemCategories(ctx, item); err != nil {
+				misc.Error("add_item_categories", fmt.Sprintf("add categories of '%s'", item.GUID), err)
+				return err
+			}
+		}
+		if err := service.CheckImage(ctx, item); err != nil {
+			service.Trace(ctx, item, entity.StageImage, entity.DecisionRejected, fmt.Sprintf("image is dropped, posted as text: %v", err))
+		}
+		if service.IsPaywallSkipped(ctx, item) {
+			service.Trace(ctx, item, entity.StagePaywall, entity.DecisionRejected, "paywalled items of the provider are skipped")
 			continue
 		}
-		item.Paywall = meta.Paywall
-		item.ImageURL = meta.ImageURL
-		if item.Description == "" {
-			item.Description = meta.Description
+		if config.HoldWindow > 0 {
+			held, err := service.HoldItem(ctx, item)
+			if err != nil {
+				misc.Error("hold_item", fmt.Sprintf("hold item '%s'", item.GUID), err)
+				return err
+			}
+			if held {
+				service.Trace(ctx, item, entity.StageHold, entity.DecisionHeld, fmt.Sprintf("waiting %s for duplicates", config.HoldWindow))
+				continue
+			}
 		}
 		if err := checkRecord(ctx, item); err != nil {
 			misc.Error("check_record", fmt.Sprintf("check record '%s'", item.GUID), err)
@@ -215,6 +511,61 @@ func addMissingEntries(ctx context.Context, items []*config.FeedItem) error {
 	return nil
 }
 
+// releaseHeldItems perform publishing of the best version of every held story whose hold window has passed,
+// the other versions are attached to the story
+func releaseHeldItems(ctx context.Context) error {
+	released, err := service.GetReleasedItems(ctx)
+	if err != nil {
+		return err
+	}
+	handled := map[string]bool{}
+	for _, pending := range released {
+		if handled[pending.EntryID] {
+			continue
+		}
+		group, err := service.GetSimilarHeldItems(ctx, pending)
+		if err != nil {
+			return err
+		}
+		best := service.ChooseBestItem(group)
+		bestCtx, err := withChannelTargets(providerContext(ctx, best.Provider, best.FeedTitle))
+		if err != nil {
+			return fmt.Errorf("failed to release held item '%s': %v", best.EntryID, err)
+		}
+		if err := checkRecord(bestCtx, best.Item); err != nil {
+			return fmt.Errorf("failed to release held item '%s': %v", best.EntryID, err)
+		}
+		var entry entity.Entry
+		dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+		if err := dbConnect.NewSelect().Model(&entry).Relation("Provider").Where("e.id = ?", best.EntryID).Scan(ctx); err != nil {
+			return fmt.Errorf("failed to release held item '%s': %v", best.EntryID, err)
+		}
+		updated := false
+		for _, other := range group {
+			handled[other.EntryID] = true
+			if other.EntryID == best.EntryID {
+				continue
+			}
+			otherCtx := providerContext(ctx, other.Provider, other.FeedTitle)
+			added, err := service.AddStorySource(otherCtx, &entry, other.Item)
+			if err != nil {
+				return fmt.Errorf("failed to release held item '%s': %v", other.EntryID, err)
+			}
+			updated = updated || added
+			service.Trace(otherCtx, other.Item, entity.StageHold, entity.DecisionRejected, fmt.Sprintf("added to story of '%s' from %s", best.EntryID, best.Provider.Name))
+		}
+		if updated {
+			if err := updateStoryMessage(ctx, &entry); err != nil {
+				misc.Error("update_story_message", fmt.Sprintf("update story message '%s'", entry.ID), err)
+			}
+		}
+		if err := service.DeleteHeldItems(ctx, group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func cleanUp(ctx context.Context) {
 	ticker := time.NewTicker(config.PurgeOldEntriesEvery)
 	quit := make(chan struct{})
@@ -223,6 +574,26 @@ func cleanUp(ctx context.Context) {
 		case <-ticker.C:
 			dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
 			_, _ = dbConnect.NewDelete().Model(&entity.Entry{}).Where("updated_at < NOW() - INTERVAL '7 days'").Exec(ctx)
+			_, _ = dbConnect.NewDelete().Model(&entity.ItemDecision{}).Where(fmt.Sprintf("updated_at < NOW() - INTERVAL '%d hours'", config.DecisionRetention/time.Hour)).Exec(ctx)
+			_, _ = dbConnect.NewDelete().Model(&entity.OutboxMessage{}).Where(fmt.Sprintf("(sent_at IS NOT NULL OR failed_at IS NOT NULL) AND created_at < NOW() - INTERVAL '%d hours'", config.OutboxRetention/time.Hour)).Exec(ctx)
+			_, _ = dbConnect.NewDelete().Model(&entity.ListingItem{}).Where(fmt.Sprintf("last_seen_at < NOW() - INTERVAL '%d hours'", config.ListingItemRetention/time.Hour)).Exec(ctx)
+			_, _ = dbConnect.NewDelete().Model(&entity.Translation{}).Where(fmt.Sprintf("created_at < NOW() - INTERVAL '%d hours'", config.TranslationRetention/time.Hour)).Exec(ctx)
+		case <-quit:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+// outboxWorker perform sending of the outbox, it is the only sender of the messages of entries
+func outboxWorker(ctx context.Context) {
+	ticker := time.NewTicker(config.OutboxTick)
+	quit := make(chan struct{})
+	for {
+		select {
+		case <-ticker.C:
+			reconcileOutbox(ctx)
+			sendOutbox(ctx)
 		case <-quit:
 			ticker.Stop()
 			return
@@ -277,20 +648,85 @@ func handleCommand(ctx context.Context, commander string) {
 	}
 }
 
+// loadSettings override the default settings by environment
+func loadSettings() {
+	if value, err := strconv.ParseFloat(os.Getenv("SIMILARITY_THRESHOLD"), 64); err == nil {
+		config.SimilarityThreshold = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("SIMILARITY_WINDOW")); err == nil {
+		config.SimilarityWindow = value
+	}
+	if value, err := time.ParseDuration(os.Getenv("HOLD_WINDOW")); err == nil {
+		config.HoldWindow = value
+	}
+	if value, err := time.LoadLocation(os.Getenv("TIME_ZONE")); err == nil && os.Getenv("TIME_ZONE") != "" {
+		config.TimeZone = value
+	}
+	config.UpdatedMarker = os.Getenv("UPDATED_MARKER") == "true"
+	if value, err := time.ParseDuration(os.Getenv("MIN_EDIT_INTERVAL")); err == nil {
+		config.MinEditInterval = value
+	}
+	if value := os.Getenv("EDIT_FIELDS"); value != "" {
+		config.SignificantFields = map[string]bool{}
+		for _, field := range strings.Split(value, ",") {
+			config.SignificantFields[strings.TrimSpace(field)] = true
+		}
+	}
+}
+
 func handleNews(ctx context.Context) {
-	chatID, err := strconv.ParseInt(os.Getenv("TELEGRAM_CHAT_ID"), 10, 64)
+	loadSettings()
+	if value := os.Getenv("TELEGRAM_CHAT_ID"); value != "" {
+		chatID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			misc.Fatal("tg_chat_id", "chat id", err)
+		}
+		sourceLang := os.Getenv("SOURCE_LANG")
+		err = entity.EnsureChannel(ctx, &entity.Channel{
+			Name:          sourceLang,
+			ChatID:        chatID,
+			SourceLangs:   []string{sourceLang},
+			TranslateLang: os.Getenv("TRANSLATE_LANG"),
+		})
+		if err != nil {
+			misc.Fatal("ensure_channel", "ensure channel", err)
+		}
+	}
+	adminChatID, _ := strconv.ParseInt(os.Getenv("ADMIN_CHAT_ID"), 10, 64)
+	ctx = context.WithValue(ctx, config.CtxAdminChatIDKey, adminChatID)
+	translator, err := service.NewTranslator(os.Getenv("TRANSLATOR"), os.Getenv("TRANSLATOR_URL"), os.Getenv("TRANSLATOR_KEY"))
 	if err != nil {
-		misc.Fatal("tg_chat_id", "chat id", err)
+		misc.Fatal("translator", "translator", err)
 	}
-	ctx = context.WithValue(ctx, config.CtxChatIDKey, chatID)
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	ctx = context.WithValue(ctx, config.CtxTranslatorKey, service.NewGlossaryTranslator(service.NewCachedTranslator(translator, dbConnect)))
 	go cleanUp(ctx)
-	job(ctx)
-	ticker := time.NewTicker(config.TimeoutBetweenLoops)
+	go outboxWorker(ctx)
+	pool := service.NewFetchPool(config.FetchWorkers, service.FetchFeed)
+	go func() {
+		releaseTicker := time.NewTicker(config.SchedulerTick)
+		for {
+			select {
+			case result := <-pool.Results():
+				job(ctx, result)
+				pool.Done(result.Provider.ID)
+			case <-releaseTicker.C:
+				if config.HoldWindow == 0 {
+					continue
+				}
+				if err := releaseHeldItems(ctx); err != nil {
+					misc.Error("release_held_items", "release held items", err)
+				}
+			}
+		}
+	}()
+	schedule(ctx, pool)
+	ticker := time.NewTicker(config.SchedulerTick)
 	quit := make(chan struct{})
 	for {
 		select {
 		case <-ticker.C:
-			job(ctx)
+			schedule(ctx, pool)
 		case <-quit:
 			ticker.Stop()
 			return
@@ -298,68 +734,186 @@ func handleNews(ctx context.Context) {
 	}
 }
 
-func job(ctx context.Context) {
-	providers := getProviders(ctx)
+// schedule perform submit of the due providers to the pool, a provider still in flight is skipped
+func schedule(ctx context.Context, pool *service.FetchPool) {
+	providers, err := entity.GetProviders(ctx)
+	if err != nil {
+		misc.Fatal("get_providers", "get providers", err)
+		return
+	}
+	channels, err := entity.GetChannels(ctx)
+	if err != nil {
+		misc.Error("get_channels", "get channels", err)
+		return
+	}
+	langs := map[string]bool{}
+	for _, channel := range channels {
+		for _, lang := range channel.SourceLangs {
+			langs[lang] = true
+		}
+	}
+	now := time.Now()
 	for _, provider := range providers {
-		if provider.Lang != os.Getenv("SOURCE_LANG") {
+		if !langs[provider.Lang] || provider.NextRunAt.After(now) || pool.IsFetching(provider.ID) {
 			continue
 		}
-		feed, err := service.GetFeed(provider.URL)
-		if err != nil {
-			misc.Fatal("get_feed", "get feed", err)
+		if err := entity.ScheduleProvider(ctx, &provider, now); err != nil {
+			misc.Error("schedule_provider", fmt.Sprintf("schedule provider '%d'", provider.ID), err)
+			continue
 		}
-		ctx = context.WithValue(ctx, config.CtxProviderKey, &provider) //nolint:gosec,gocritic
-		ctx = context.WithValue(ctx, config.CtxFeedTitleKey, feed.Title)
-		ctx = context.WithValue(ctx, config.CtxTranslateLangKey, os.Getenv("TRANSLATE_LANG"))
-		blocks, err := entity.GetListBlocks(ctx)
-		if err != nil {
-			misc.Fatal("get_blocked_categories", "get blocked categories", err)
-		}
-		blocks = funk.Filter(blocks, func(item entity.BlockedCategory) bool {
-			return item.Category.ProviderID == provider.ID
-		}).([]entity.BlockedCategory)
-		blockedCategories := funk.Map(blocks, func(item entity.BlockedCategory) string {
-			return item.Category.Name
-		}).([]string)
-		categoriesMap, err := service.AddMissedCategories(ctx, feed.Items)
+		pool.Submit(provider)
+	}
+}
+
+// job is an error boundary of a provider, a failure to fetch or parse the feed is recorded and never affects
+// the other providers, a failure to process the feed is not a failure of the provider
+func job(ctx context.Context, result service.FetchResult) {
+	provider := result.Provider
+	if result.Err != nil && !errors.Is(result.Err, service.ErrNotModified) {
+		misc.Error("fetch_feed", fmt.Sprintf("fetch feed '%s'", provider.URL), result.Err)
+		opened, err := entity.RecordProviderFailure(ctx, &provider, result.Err, time.Now())
 		if err != nil {
-			misc.Fatal("add_missed_categories", "add missed categories", err)
+			misc.Error("record_provider_failure", fmt.Sprintf("record failure of provider '%d'", provider.ID), err)
+			return
 		}
-		chatID := ctx.Value(config.CtxChatIDKey).(int64)
-		items := funk.Map(feed.Items, func(item *gofeed.Item) *config.FeedItem {
-			path := item.Link
-			if item.GUID != "" {
-				path = item.GUID
-			}
-			guid, err := misc.FormatGUID(path)
-			if err != nil {
-				misc.Fatal("format_guid", fmt.Sprintf("format guid for path '%s'", path), err)
-			}
-			categoriesIDs := funk.Map(item.Categories, func(category string) int {
-				return categoriesMap[category]
-			}).([]int)
-			return &config.FeedItem{
-				GUID:          fmt.Sprintf("%s%d", guid, chatID),
-				Link:          item.Link,
-				Title:         item.Title,
-				Description:   item.Description,
-				Categories:    item.Categories,
-				Published:     item.Published,
-				CategoriesIDs: categoriesIDs,
-			}
-		}).([]*config.FeedItem)
-		items = funk.Filter(items, isValidItemByTerm).([]*config.FeedItem)
-		items = funk.Filter(items, func(item *config.FeedItem) bool {
-			return isValidItemByContent(blockedCategories, provider.BlockedWords, provider.BlockedDomains, item)
-		}).([]*config.FeedItem)
-		sort.Slice(items, func(i, j int) bool {
-			return items[i].Published > items[j].Published
-		})
-		if err := deleteDeletedEntries(ctx, items); err != nil {
-			misc.Fatal("delete_record", "delete record", err)
+		if opened {
+			notifyAdmin(ctx, fmt.Sprintf("provider %d %s is paused until %s after %d failures: %s", provider.ID, provider.URL, provider.CircuitOpenUntil.Format(time.DateTime), provider.FailureCount, provider.LastError))
 		}
-		if err := addMissingEntries(ctx, items); err != nil {
-			misc.Fatal("add_edit_record", "add/edit record", err)
+		return
+	}
+	if provider.FailureCount > 0 {
+		circuitWasOpen := !provider.CircuitOpenUntil.IsZero()
+		if err := entity.RecordProviderSuccess(ctx, &provider); err != nil {
+			misc.Error("record_provider_success", fmt.Sprintf("record success of provider '%d'", provider.ID), err)
+		} else if circuitWasOpen {
+			notifyAdmin(ctx, fmt.Sprintf("provider %d %s is recovered", provider.ID, provider.URL))
 		}
 	}
+	if errors.Is(result.Err, service.ErrNotModified) {
+		misc.Info(fmt.Sprintf("feed '%s' is not modified", provider.URL))
+		return
+	}
+	if err := safeProcessFeed(ctx, &provider, result.Feed); err != nil {
+		misc.Error("process_feed", fmt.Sprintf("process feed '%s'", provider.URL), err)
+	}
+}
+
+func notifyAdmin(ctx context.Context, text string) {
+	adminChatID := ctx.Value(config.CtxAdminChatIDKey).(int64)
+	if adminChatID == 0 {
+		return
+	}
+	bot := ctx.Value(config.CtxBotKey).(*tgbotapi.BotAPI)
+	if _, err := bot.Send(tgbotapi.NewMessage(adminChatID, text)); err != nil {
+		misc.Error("notify_admin", "notify admin", err)
+	}
+}
+
+func safeProcessFeed(ctx context.Context, provider *entity.Provider, feed *gofeed.Feed) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return processFeed(ctx, provider, feed)
+}
+
+func providerContext(ctx context.Context, provider *entity.Provider, feedTitle string) context.Context {
+	ctx = context.WithValue(ctx, config.CtxProviderKey, provider)
+	ctx = context.WithValue(ctx, config.CtxFeedTitleKey, feedTitle)
+	return ctx
+}
+
+// withChannelTargets return context with the channels publishing items of the provider
+func withChannelTargets(ctx context.Context) (context.Context, error) {
+	targets, err := service.GetChannelTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, config.CtxChannelsKey, targets), nil
+}
+
+// channelContext return context of publishing to the channel, the translate language of the channel
+// takes precedence over the one of the provider
+func channelContext(ctx context.Context, channel *entity.Channel) context.Context {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	ctx = context.WithValue(ctx, config.CtxChannelKey, channel)
+	ctx = context.WithValue(ctx, config.CtxChatIDKey, channel.ChatID)
+	translateLang := cmp.Or(channel.TranslateLang, provider.TranslateLang, os.Getenv("TRANSLATE_LANG"))
+	return context.WithValue(ctx, config.CtxTranslateLangKey, translateLang)
+}
+
+func processFeed(ctx context.Context, provider *entity.Provider, feed *gofeed.Feed) error {
+	ctx, err := withChannelTargets(providerContext(ctx, provider, feed.Title))
+	if err != nil {
+		return fmt.Errorf("failed to get channels: %v", err)
+	}
+	blocks, err := entity.GetListBlocks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get blocked categories: %v", err)
+	}
+	blocks = funk.Filter(blocks, func(item entity.BlockedCategory) bool {
+		return item.Category.ProviderID == provider.ID
+	}).([]entity.BlockedCategory)
+	rules, err := entity.GetFilterRules(ctx, provider.ID, 0)
+	if err != nil {
+		return fmt.Errorf("failed to get filter rules: %v", err)
+	}
+	for _, block := range blocks {
+		rules = append(rules, &entity.FilterRule{
+			Action:        entity.FilterActionBlock,
+			Field:         entity.FilterFieldCategory,
+			Match:         entity.FilterMatchExact,
+			Pattern:       block.Category.Name,
+			CaseSensitive: true,
+		})
+	}
+	filter := service.NewFilter(rules)
+	categoriesMap, err := service.AddMissedCategories(ctx, feed.Items)
+	if err != nil {
+		return fmt.Errorf("failed to add missed categories: %v", err)
+	}
+	if err := service.DateListingItems(ctx, provider, feed, time.Now()); err != nil {
+		return fmt.Errorf("failed to date listing items: %v", err)
+	}
+	items := make([]*config.FeedItem, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		guid, err := service.ExtractGUID(provider, item)
+		if err != nil {
+			misc.Error("extract_guid", fmt.Sprintf("extract guid for link '%s'", item.Link), err)
+			continue
+		}
+		categoriesIDs := funk.Map(item.Categories, func(category string) int {
+			return categoriesMap[category]
+		}).([]int)
+		items = append(items, &config.FeedItem{
+			GUID:          guid,
+			Link:          item.Link,
+			Title:         item.Title,
+			Description:   item.Description,
+			Author:        getAuthor(item),
+			Categories:    item.Categories,
+			Published:     item.Published,
+			CategoriesIDs: categoriesIDs,
+		})
+	}
+	items = funk.Filter(items, func(item *config.FeedItem) bool {
+		return isValidItemByTerm(ctx, item)
+	}).([]*config.FeedItem)
+	items = funk.Filter(items, func(item *config.FeedItem) bool {
+		return isValidItemByContent(ctx, filter, item)
+	}).([]*config.FeedItem)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Published > items[j].Published
+	})
+	if err := deleteDeletedEntries(ctx, items); err != nil {
+		return fmt.Errorf("failed to delete records: %v", err)
+	}
+	if err := addMissingEntries(ctx, items); err != nil {
+		return fmt.Errorf("failed to add/edit records: %v", err)
+	}
+	if err := service.SaveFeedCache(ctx, provider); err != nil {
+		misc.Error("save_feed_cache", "save feed cache", err)
+	}
+	return nil
 }
@@ -0,0 +1,3 @@
+ALTER TABLE "providers"
+    ADD COLUMN "type" text NOT NULL DEFAULT 'rss',
+    ADD COLUMN "selectors" jsonb;
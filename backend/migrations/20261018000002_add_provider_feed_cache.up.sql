@@ -0,0 +1,4 @@
+ALTER TABLE "providers"
+    ADD COLUMN "etag" text,
+    ADD COLUMN "last_modified" text,
+    ADD COLUMN "content_hash" text;
@@ -0,0 +1,5 @@
+ALTER TABLE "providers"
+    ADD COLUMN "poll_interval" int NOT NULL DEFAULT 300,
+    ADD COLUMN "poll_jitter" int NOT NULL DEFAULT 30,
+    ADD COLUMN "last_run_at" timestamptz,
+    ADD COLUMN "next_run_at" timestamptz;
@@ -0,0 +1,4 @@
+ALTER TABLE "providers"
+    ADD COLUMN "failure_count" int NOT NULL DEFAULT 0,
+    ADD COLUMN "last_error" text,
+    ADD COLUMN "circuit_open_until" timestamptz;
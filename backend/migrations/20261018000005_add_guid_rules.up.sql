@@ -0,0 +1,27 @@
+CREATE SEQUENCE IF NOT EXISTS guid_rules_id_seq;
+CREATE TABLE "guid_rules" (
+    "id" int8 NOT NULL DEFAULT nextval('guid_rules_id_seq'::regclass),
+    "provider_id" int8 NOT NULL,
+    "priority" int NOT NULL DEFAULT 0,
+    "field" text NOT NULL DEFAULT 'guid',
+    "param" text,
+    "pattern" text NOT NULL,
+    "prefix" text NOT NULL,
+    CONSTRAINT "fk_guid_rules_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+
+INSERT INTO "guid_rules" ("provider_id", "priority", "field", "pattern", "prefix")
+    SELECT "id", 1, 'guid', 'err.*?/(\d+)$', 'err' FROM "providers" WHERE "name" = 'ERR';
+INSERT INTO "guid_rules" ("provider_id", "priority", "field", "pattern", "prefix")
+    SELECT "id", 0, 'link', 'err.*?/(\d+)$', 'err' FROM "providers" WHERE "name" = 'ERR';
+INSERT INTO "guid_rules" ("provider_id", "priority", "field", "pattern", "prefix")
+    SELECT "id", 2, 'guid', 'delfi.*?/(\d+)/.*?$', 'delfi' FROM "providers" WHERE "name" = 'Delfi';
+INSERT INTO "guid_rules" ("provider_id", "priority", "field", "param", "pattern", "prefix")
+    SELECT "id", 1, 'query', 'id', '^(\d+)$', 'delfi' FROM "providers" WHERE "name" = 'Delfi';
+INSERT INTO "guid_rules" ("provider_id", "priority", "field", "pattern", "prefix")
+    SELECT "id", 0, 'link', 'delfi.*?/(\d+)/.*?$', 'delfi' FROM "providers" WHERE "name" = 'Delfi';
+INSERT INTO "guid_rules" ("provider_id", "priority", "field", "pattern", "prefix")
+    SELECT "id", 1, 'guid', 'postimees\.ee/(\d+)', 'pm' FROM "providers" WHERE "name" = 'Postimees';
+INSERT INTO "guid_rules" ("provider_id", "priority", "field", "pattern", "prefix")
+    SELECT "id", 0, 'link', 'postimees\.ee/(\d+)', 'pm' FROM "providers" WHERE "name" = 'Postimees';
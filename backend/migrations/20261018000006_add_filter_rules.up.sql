@@ -0,0 +1,23 @@
+CREATE SEQUENCE IF NOT EXISTS filter_rules_id_seq;
+CREATE TABLE "filter_rules" (
+    "id" int8 NOT NULL DEFAULT nextval('filter_rules_id_seq'::regclass),
+    "provider_id" int8,
+    "action" text NOT NULL DEFAULT 'block',
+    "field" text NOT NULL DEFAULT 'any',
+    "match" text NOT NULL DEFAULT 'substring',
+    "pattern" text NOT NULL,
+    "case_sensitive" bool NOT NULL DEFAULT 'false',
+    "priority" int NOT NULL DEFAULT 0,
+    "hits" int8 NOT NULL DEFAULT 0,
+    CONSTRAINT "fk_filter_rules_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+
+INSERT INTO "filter_rules" ("provider_id", "action", "field", "match", "pattern", "case_sensitive")
+    SELECT "id", 'block', 'text', 'substring', "word", 'true' FROM "providers", unnest("blocked_words") AS "word" WHERE "word" <> '';
+INSERT INTO "filter_rules" ("provider_id", "action", "field", "match", "pattern", "case_sensitive")
+    SELECT "id", 'block', 'link', 'substring', "domain", 'true' FROM "providers", unnest("blocked_domains") AS "domain" WHERE "domain" <> '';
+
+ALTER TABLE "providers"
+    DROP COLUMN "blocked_words",
+    DROP COLUMN "blocked_domains";
@@ -0,0 +1,14 @@
+CREATE TABLE "item_decisions" (
+    "entry_id" text NOT NULL,
+    "stage" text NOT NULL,
+    "provider_id" int8 NOT NULL,
+    "link" text,
+    "title" text,
+    "decision" text NOT NULL,
+    "reason" text,
+    "updated_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_item_decisions_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("entry_id","stage")
+);
+CREATE INDEX "idx_item_decisions_link" ON "item_decisions"("link");
+CREATE INDEX "idx_item_decisions_updated_at" ON "item_decisions"("updated_at");
@@ -0,0 +1,21 @@
+CREATE SEQUENCE IF NOT EXISTS stories_id_seq;
+CREATE TABLE "stories" (
+    "id" int8 NOT NULL DEFAULT nextval('stories_id_seq'::regclass),
+    "entry_id" text NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_stories_entry" FOREIGN KEY ("entry_id") REFERENCES "entries"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "uniq_idx_stories" ON "stories"("entry_id");
+
+CREATE TABLE "story_sources" (
+    "story_id" int8 NOT NULL,
+    "entry_id" text NOT NULL,
+    "provider_id" int8 NOT NULL,
+    "link" text,
+    "title" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_story_sources_story" FOREIGN KEY ("story_id") REFERENCES "stories"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "fk_story_sources_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("story_id","entry_id")
+);
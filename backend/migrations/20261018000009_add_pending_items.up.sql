@@ -0,0 +1,15 @@
+ALTER TABLE "providers"
+    ADD COLUMN "priority" int NOT NULL DEFAULT 0;
+
+CREATE TABLE "pending_items" (
+    "entry_id" text NOT NULL,
+    "provider_id" int8 NOT NULL,
+    "title" text,
+    "feed_title" text,
+    "item" jsonb NOT NULL,
+    "release_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_pending_items_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("entry_id")
+);
+CREATE INDEX "idx_pending_items_release_at" ON "pending_items"("release_at");
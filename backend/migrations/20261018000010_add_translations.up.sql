@@ -0,0 +1,12 @@
+ALTER TABLE "providers"
+    ADD COLUMN "translate_lang" text;
+
+CREATE TABLE "translations" (
+    "hash" text NOT NULL,
+    "source_lang" text NOT NULL,
+    "target_lang" text NOT NULL,
+    "text" text NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY ("hash")
+);
+CREATE INDEX "idx_translations_created_at" ON "translations"("created_at");
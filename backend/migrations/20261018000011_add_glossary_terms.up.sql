@@ -0,0 +1,15 @@
+CREATE SEQUENCE IF NOT EXISTS glossary_terms_id_seq;
+CREATE TABLE "glossary_terms" (
+    "id" int8 NOT NULL DEFAULT nextval('glossary_terms_id_seq'::regclass),
+    "source_lang" text NOT NULL,
+    "target_lang" text NOT NULL DEFAULT '',
+    "term" text NOT NULL,
+    "translation" text NOT NULL DEFAULT '',
+    PRIMARY KEY ("id"),
+    UNIQUE ("source_lang", "target_lang", "term")
+);
+
+INSERT INTO "glossary_terms" ("source_lang", "term") VALUES
+    ('et', 'Riigikogu'),
+    ('et', 'Isamaa'),
+    ('et', 'Eesti 200');
@@ -0,0 +1,11 @@
+CREATE SEQUENCE IF NOT EXISTS templates_id_seq;
+CREATE TABLE "templates" (
+    "id" int8 NOT NULL DEFAULT nextval('templates_id_seq'::regclass),
+    "provider_id" int8,
+    "chat_id" int8,
+    "name" text NOT NULL,
+    "body" text NOT NULL,
+    CONSTRAINT "fk_templates_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "idx_templates_scope" ON "templates"(COALESCE("provider_id", 0), COALESCE("chat_id", 0), "name");
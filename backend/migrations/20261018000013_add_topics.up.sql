@@ -0,0 +1,26 @@
+CREATE SEQUENCE IF NOT EXISTS topics_id_seq;
+CREATE TABLE "topics" (
+    "id" int8 NOT NULL DEFAULT nextval('topics_id_seq'::regclass),
+    "name" text NOT NULL,
+    "hashtag" text NOT NULL,
+    PRIMARY KEY ("id"),
+    UNIQUE ("name")
+);
+
+CREATE TABLE "category_to_topics" (
+    "category_id" int8 NOT NULL,
+    "topic_id" int8 NOT NULL,
+    CONSTRAINT "fk_category_to_topics_category" FOREIGN KEY ("category_id") REFERENCES "categories"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "fk_category_to_topics_topic" FOREIGN KEY ("topic_id") REFERENCES "topics"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("category_id", "topic_id")
+);
+
+INSERT INTO "topics" ("name", "hashtag") VALUES
+    ('Politics', '#politics'),
+    ('Economy', '#economy'),
+    ('Sport', '#sport'),
+    ('Culture', '#culture'),
+    ('World', '#world'),
+    ('Science', '#science'),
+    ('Health', '#health'),
+    ('Estonia', '#estonia');
@@ -0,0 +1,4 @@
+ALTER TABLE "entries"
+    ADD COLUMN "message_type" text NOT NULL DEFAULT 'text';
+
+UPDATE "entries" SET "message_type" = 'photo' WHERE "image_url" <> '';
@@ -0,0 +1,16 @@
+CREATE SEQUENCE IF NOT EXISTS entry_revisions_id_seq;
+CREATE TABLE "entry_revisions" (
+    "id" int8 NOT NULL DEFAULT nextval('entry_revisions_id_seq'::regclass),
+    "entry_id" text NOT NULL,
+    "link" text,
+    "title" text,
+    "description" text,
+    "image_url" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_entry_revisions_entry" FOREIGN KEY ("entry_id") REFERENCES "entries"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_entry_revisions_entry_id" ON "entry_revisions"("entry_id", "created_at");
+
+INSERT INTO "entry_revisions" ("entry_id", "link", "title", "description", "image_url", "created_at")
+    SELECT "id", "link", "title", "description", "image_url", "updated_at" FROM "entries";
@@ -0,0 +1,22 @@
+ALTER TABLE "providers"
+    ADD COLUMN "retraction_policy" text NOT NULL DEFAULT 'delete';
+
+ALTER TABLE "entries"
+    ADD COLUMN "retracted_at" timestamptz;
+
+CREATE SEQUENCE IF NOT EXISTS retracted_entries_id_seq;
+CREATE TABLE "retracted_entries" (
+    "id" int8 NOT NULL DEFAULT nextval('retracted_entries_id_seq'::regclass),
+    "entry_id" text NOT NULL,
+    "provider_id" int8 NOT NULL,
+    "message_id" int8,
+    "link" text,
+    "title" text,
+    "description" text,
+    "policy" text NOT NULL,
+    "reason" text,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_retracted_entries_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX "idx_retracted_entries_created_at" ON "retracted_entries"("created_at");
@@ -0,0 +1,9 @@
+ALTER TABLE "providers"
+    ADD COLUMN "availability" jsonb;
+
+UPDATE "providers" SET "availability" = '{"status_codes": [404, 410], "markers": ["Artiklit ei leitud", "Статья не найдена"], "redirect_to_root": true}'
+    WHERE "name" = 'ERR';
+UPDATE "providers" SET "availability" = '{"status_codes": [404, 410], "markers": ["Artiklit ei leitud", "Lehekülge ei leitud", "Статья не найдена", "Страница не найдена"], "redirect_to_root": true, "canonical_mismatch": true}'
+    WHERE "name" = 'Delfi';
+UPDATE "providers" SET "availability" = '{"status_codes": [404, 410], "markers": ["Artiklit ei leitud", "Lehte ei leitud", "Статья не найдена", "Страница не найдена"], "redirect_to_root": true, "canonical_mismatch": true}'
+    WHERE "name" = 'Postimees';
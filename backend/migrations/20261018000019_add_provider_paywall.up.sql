@@ -0,0 +1,14 @@
+ALTER TABLE "providers"
+    ADD COLUMN "paywall" jsonb,
+    ADD COLUMN "paywall_policy" text NOT NULL DEFAULT 'publish',
+    ADD COLUMN "paywall_chat_id" int8;
+
+ALTER TABLE "entries"
+    ADD COLUMN "chat_id" int8;
+
+UPDATE "providers" SET "paywall" = '{"accessible_for_free": true, "classes": ["fragment--teaser"], "patterns": ["\"isPremium\"\\s*:\\s*true"]}'
+    WHERE "name" = 'Postimees';
+UPDATE "providers" SET "paywall" = '{"accessible_for_free": true, "classes": ["paywall", "C-paywall"], "patterns": ["\"isPremium\"\\s*:\\s*true", "\"premium\"\\s*:\\s*true"]}'
+    WHERE "name" = 'Delfi';
+UPDATE "providers" SET "paywall" = '{"accessible_for_free": true}'
+    WHERE "name" = 'ERR';
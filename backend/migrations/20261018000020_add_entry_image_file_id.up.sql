@@ -0,0 +1,4 @@
+ALTER TABLE "entries"
+    ADD COLUMN "image_file_id" text;
+
+CREATE INDEX "idx_entries_image_url" ON "entries"("image_url") WHERE "image_file_id" IS NOT NULL;
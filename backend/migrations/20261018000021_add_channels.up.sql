@@ -0,0 +1,86 @@
+CREATE SEQUENCE IF NOT EXISTS channels_id_seq;
+CREATE TABLE "channels" (
+    "id" int8 NOT NULL DEFAULT nextval('channels_id_seq'::regclass),
+    "name" text,
+    "chat_id" int8 NOT NULL,
+    "source_langs" text[] NOT NULL DEFAULT '{}',
+    "translate_lang" text,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "uniq_idx_channels_chat_id" ON "channels"("chat_id");
+
+ALTER TABLE "filter_rules"
+    ADD COLUMN "channel_id" int8,
+    ADD CONSTRAINT "fk_filter_rules_channel" FOREIGN KEY ("channel_id") REFERENCES "channels"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+-- ids of entries end with the chat of the process published them, every chat becomes a channel
+CREATE TEMPORARY TABLE "entry_ids" AS
+    SELECT "id" AS "old_id",
+        regexp_replace("id", '-\d+$', '') AS "new_id",
+        substring("id" from '(-\d+)$')::int8 AS "chat_id",
+        row_number() OVER (PARTITION BY regexp_replace("id", '-\d+$', '') ORDER BY "updated_at" DESC) AS "rank"
+    FROM "entries"
+    WHERE "id" ~ '-\d+$';
+
+INSERT INTO "channels" ("name", "chat_id", "source_langs")
+    SELECT string_agg(DISTINCT p."lang", ' '), m."chat_id", array_agg(DISTINCT p."lang")
+    FROM "entry_ids" m
+    JOIN "entries" e ON e."id" = m."old_id"
+    JOIN "providers" p ON p."id" = e."provider_id"
+    GROUP BY m."chat_id";
+
+CREATE TABLE "entry_messages" (
+    "entry_id" text NOT NULL,
+    "channel_id" int8 NOT NULL,
+    "chat_id" int8 NOT NULL,
+    "message_id" int8 NOT NULL,
+    "message_type" text NOT NULL DEFAULT 'text',
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_entry_messages_channel" FOREIGN KEY ("channel_id") REFERENCES "channels"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("entry_id","channel_id")
+);
+
+INSERT INTO "entry_messages" ("entry_id", "channel_id", "chat_id", "message_id", "message_type", "created_at")
+    SELECT m."new_id", c."id", COALESCE(e."chat_id", m."chat_id"), e."message_id", e."message_type", e."updated_at"
+    FROM "entries" e
+    JOIN "entry_ids" m ON m."old_id" = e."id"
+    JOIN "channels" c ON c."chat_id" = m."chat_id"
+    WHERE e."message_id" <> 0
+    ON CONFLICT DO NOTHING;
+
+-- the same item published in several chats becomes one entry with a message per channel
+DELETE FROM "entries" WHERE "id" IN (SELECT "old_id" FROM "entry_ids" WHERE "rank" > 1);
+UPDATE "entries" e SET "id" = m."new_id" FROM "entry_ids" m WHERE m."old_id" = e."id";
+
+DROP TABLE "entry_ids";
+
+ALTER TABLE "entry_messages"
+    ADD CONSTRAINT "fk_entry_messages_entry" FOREIGN KEY ("entry_id") REFERENCES "entries"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE "entries"
+    DROP COLUMN "message_id",
+    DROP COLUMN "message_type",
+    DROP COLUMN "chat_id";
+
+-- the other tables refer to entries by id without a foreign key
+DELETE FROM "item_decisions" d USING "item_decisions" o
+    WHERE d."stage" = o."stage" AND d."entry_id" <> o."entry_id"
+    AND regexp_replace(d."entry_id", '-\d+$', '') = regexp_replace(o."entry_id", '-\d+$', '')
+    AND (d."updated_at", d."entry_id") < (o."updated_at", o."entry_id");
+UPDATE "item_decisions" SET "entry_id" = regexp_replace("entry_id", '-\d+$', '') WHERE "entry_id" ~ '-\d+$';
+
+DELETE FROM "pending_items" d USING "pending_items" o
+    WHERE d."entry_id" <> o."entry_id"
+    AND regexp_replace(d."entry_id", '-\d+$', '') = regexp_replace(o."entry_id", '-\d+$', '')
+    AND (d."created_at", d."entry_id") < (o."created_at", o."entry_id");
+UPDATE "pending_items" SET "entry_id" = regexp_replace("entry_id", '-\d+$', ''),
+    "item" = jsonb_set("item", '{GUID}', to_jsonb(regexp_replace("entry_id", '-\d+$', '')))
+    WHERE "entry_id" ~ '-\d+$';
+
+DELETE FROM "story_sources" d USING "story_sources" o
+    WHERE d."story_id" = o."story_id" AND d."entry_id" <> o."entry_id"
+    AND regexp_replace(d."entry_id", '-\d+$', '') = regexp_replace(o."entry_id", '-\d+$', '')
+    AND (d."created_at", d."entry_id") < (o."created_at", o."entry_id");
+UPDATE "story_sources" SET "entry_id" = regexp_replace("entry_id", '-\d+$', '') WHERE "entry_id" ~ '-\d+$';
+
+UPDATE "retracted_entries" SET "entry_id" = regexp_replace("entry_id", '-\d+$', '') WHERE "entry_id" ~ '-\d+$';
@@ -0,0 +1,25 @@
+CREATE SEQUENCE IF NOT EXISTS outbox_id_seq;
+CREATE TABLE "outbox" (
+    "id" int8 NOT NULL DEFAULT nextval('outbox_id_seq'::regclass),
+    "idempotency_key" text NOT NULL,
+    "operation" text NOT NULL,
+    "entry_id" text NOT NULL,
+    "provider_id" int8 NOT NULL,
+    "channel_id" int8 NOT NULL,
+    "chat_id" int8,
+    "message_id" int8,
+    "image_url" text,
+    "feed_title" text,
+    "item" jsonb,
+    "attempts" int NOT NULL DEFAULT 0,
+    "last_error" text,
+    "next_attempt_at" timestamptz NOT NULL DEFAULT now(),
+    "sent_at" timestamptz,
+    "failed_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT "fk_outbox_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "fk_outbox_channel" FOREIGN KEY ("channel_id") REFERENCES "channels"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("id")
+);
+CREATE UNIQUE INDEX "uniq_idx_outbox_idempotency_key" ON "outbox"("idempotency_key");
+CREATE INDEX "idx_outbox_next_attempt_at" ON "outbox"("next_attempt_at") WHERE "sent_at" IS NULL AND "failed_at" IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE "channels"
+    ADD COLUMN "paywall_chat_id" int8;
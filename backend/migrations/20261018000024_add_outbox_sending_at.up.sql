@@ -0,0 +1,2 @@
+ALTER TABLE "outbox"
+    ADD COLUMN "sending_at" timestamptz;
@@ -0,0 +1,7 @@
+ALTER TABLE "item_decisions"
+    DROP CONSTRAINT "item_decisions_pkey",
+    ADD COLUMN "id" bigserial PRIMARY KEY,
+    ADD COLUMN "channel_id" int8,
+    ADD CONSTRAINT "fk_item_decisions_channel" FOREIGN KEY ("channel_id") REFERENCES "channels"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+-- decisions at the stages before the channels have no channel
+CREATE UNIQUE INDEX "uniq_idx_item_decisions_entry_id_stage_channel_id" ON "item_decisions"("entry_id", "stage", COALESCE("channel_id", 0));
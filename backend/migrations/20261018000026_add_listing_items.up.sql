@@ -0,0 +1,9 @@
+CREATE TABLE "listing_items" (
+    "provider_id" int8 NOT NULL,
+    "link" text NOT NULL,
+    "first_seen_at" timestamptz NOT NULL,
+    "last_seen_at" timestamptz NOT NULL,
+    CONSTRAINT "fk_listing_items_provider" FOREIGN KEY ("provider_id") REFERENCES "providers"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY ("provider_id", "link")
+);
+CREATE INDEX "idx_listing_items_last_seen_at" ON "listing_items"("last_seen_at");
@@ -0,0 +1,11 @@
+ALTER TABLE "templates"
+    ADD COLUMN "channel_id" int8,
+    ADD CONSTRAINT "fk_templates_channel" FOREIGN KEY ("channel_id") REFERENCES "channels"("id") ON DELETE CASCADE ON UPDATE CASCADE;
+
+UPDATE "templates" SET "channel_id" = c."id" FROM "channels" c WHERE c."chat_id" = "templates"."chat_id";
+DELETE FROM "templates" WHERE "chat_id" IS NOT NULL AND "channel_id" IS NULL;
+
+DROP INDEX "idx_templates_scope";
+ALTER TABLE "templates"
+    DROP COLUMN "chat_id";
+CREATE UNIQUE INDEX "idx_templates_scope" ON "templates"(COALESCE("provider_id", 0), COALESCE("channel_id", 0), "name");
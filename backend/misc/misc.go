@@ -2,10 +2,6 @@
 package misc
 
 import (
-	"errors"
-	"fmt"
-	"regexp"
-
 	"github.com/go-pkgz/lgr"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/push"
@@ -38,26 +34,3 @@ func PushMetrics() {
 		taskErrors.Reset()
 	}
 }
-
-// FormatGUID return formated GUID
-func FormatGUID(path string) (string, error) {
-	var r *regexp.Regexp
-	r = regexp.MustCompile(`^\w+#\d+$`)
-	if r.MatchString(path) {
-		return path, nil
-	}
-	r = regexp.MustCompile(`err.*?/(\d+)$`)
-	if r.MatchString(path) {
-		return fmt.Sprintf("err#%s", r.FindStringSubmatch(path)[1]), nil
-	}
-	r = regexp.MustCompile(`delfi.*?/(\d+)/.*?$`)
-	if r.MatchString(path) {
-		return fmt.Sprintf("delfi#%s", r.FindStringSubmatch(path)[1]), nil
-	}
-	r = regexp.MustCompile(`delfi.*?id=(\d+)$`)
-	if r.MatchString(path) {
-		return fmt.Sprintf("delfi#%s", r.FindStringSubmatch(path)[1]), nil
-	}
-	return "", errors.New("empty GUID")
-
-}
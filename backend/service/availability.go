@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"estonia-news/entity"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultAvailability is the availability rules of a provider without its own rules
+var DefaultAvailability = entity.Availability{
+	StatusCodes: []int{404},
+	Markers:     []string{"Artiklit ei leitud"},
+}
+
+func isRootURL(link string) bool {
+	parsed, err := url.Parse(link)
+	return err == nil && strings.Trim(parsed.Path, "/") == ""
+}
+
+func getURLPath(link string) string {
+	parsed, err := url.Parse(CanonicalURL(link))
+	if err != nil {
+		return link
+	}
+	return strings.TrimRight(parsed.Path, "/")
+}
+
+var articleIDRe = regexp.MustCompile(`\d{5,}`)
+
+// getArticleID return the numeric article id in the path of link, an empty id is returned if there is no id
+func getArticleID(link string) string {
+	return articleIDRe.FindString(getURLPath(link))
+}
+
+func getCanonicalLink(body []byte, pageURL string) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	href := strings.TrimSpace(doc.Find(`link[rel="canonical"]`).First().AttrOr("href", ""))
+	if href == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// DetectUnavailable return the reason why the article of link is removed by the response for it,
+// an empty reason is returned if the article is available
+func DetectUnavailable(rules *entity.Availability, link string, statusCode int, finalURL string, body []byte) string {
+	if rules == nil {
+		rules = &DefaultAvailability
+	}
+	if slices.Contains(rules.StatusCodes, statusCode) {
+		return fmt.Sprintf("status code %d", statusCode)
+	}
+	if statusCode >= 400 {
+		return ""
+	}
+	for _, marker := range rules.Markers {
+		if marker != "" && bytes.Contains(body, []byte(marker)) {
+			return fmt.Sprintf("page contains '%s'", marker)
+		}
+	}
+	if rules.RedirectToRoot && isRootURL(finalURL) && !isRootURL(link) {
+		return fmt.Sprintf("redirected to '%s'", finalURL)
+	}
+	if rules.CanonicalMismatch {
+		canonical := getCanonicalLink(body, finalURL)
+		// a renamed article keeps its id, whether its page is redirected to the new link or not
+		if canonical != "" && getURLPath(canonical) != getURLPath(finalURL) && (getArticleID(canonical) == "" || getArticleID(canonical) != getArticleID(link)) {
+			return fmt.Sprintf("canonical link is '%s'", canonical)
+		}
+	}
+	return ""
+}
+
+// IsLinkUnavailable return whether the article of link is removed by the availability rules of provider and the reason
+func IsLinkUnavailable(provider *entity.Provider, link string) (bool, string) {
+	statusCode, finalURL := 200, link
+	body, res, err := httpGet(link, nil)
+	if err != nil {
+		code, convErr := strconv.Atoi(err.Error())
+		if convErr != nil {
+			return false, ""
+		}
+		statusCode = code
+	} else {
+		statusCode = res.StatusCode
+		finalURL = res.Request.URL.String()
+	}
+	reason := DetectUnavailable(provider.Availability, link, statusCode, finalURL, body)
+	return reason != "", reason
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"html"
+	"regexp"
+	"strings"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+)
+
+// Fields of an entry compared to detect changes
+const (
+	ChangeFieldTitle       = "title"
+	ChangeFieldDescription = "description"
+	ChangeFieldLink        = "link"
+	ChangeFieldImage       = "image"
+	ChangeFieldPaywall     = "paywall"
+)
+
+var tagRegexp = regexp.MustCompile(`<[^>]*>`)
+
+// NormalizeText return text without tags, entities and repeated whitespace
+func NormalizeText(text string) string {
+	text = tagRegexp.ReplaceAllString(CleanUpText(text), " ")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// GetChanges return fields of item changed since entry, text is compared normalized and links canonical
+func GetChanges(item *config.FeedItem, entry entity.Entry) []string {
+	var changes []string
+	if NormalizeText(item.Title) != NormalizeText(entry.Title) {
+		changes = append(changes, ChangeFieldTitle)
+	}
+	if NormalizeText(item.Description) != NormalizeText(entry.Description) {
+		changes = append(changes, ChangeFieldDescription)
+	}
+	if CanonicalURL(item.Link) != CanonicalURL(entry.Link) {
+		changes = append(changes, ChangeFieldLink)
+	}
+	if CanonicalURL(item.ImageURL) != CanonicalURL(entry.ImageURL) {
+		changes = append(changes, ChangeFieldImage)
+	}
+	if item.Paywall != entry.Paywall {
+		changes = append(changes, ChangeFieldPaywall)
+	}
+	return changes
+}
+
+// GetSignificantChanges return changed fields of item which are significant to edit the message
+func GetSignificantChanges(item *config.FeedItem, entry entity.Entry) []string {
+	var changes []string
+	for _, field := range GetChanges(item, entry) {
+		if config.SignificantFields[field] {
+			changes = append(changes, field)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+)
+
+// ChannelTarget is a channel publishing items of the provider with the filter rules of the channel
+type ChannelTarget struct {
+	Channel *entity.Channel
+	Filter  *Filter
+}
+
+// GetChannelTargets return channels of the language of the provider with their filters
+func GetChannelTargets(ctx context.Context) ([]*ChannelTarget, error) {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	channels, err := entity.GetChannelsByLang(ctx, provider.Lang)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]*ChannelTarget, 0, len(channels))
+	for _, channel := range channels {
+		rules, err := entity.GetFilterRules(ctx, provider.ID, channel.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get channels of provider %d: %v", provider.ID, err)
+		}
+		targets = append(targets, &ChannelTarget{Channel: channel, Filter: NewFilter(rules)})
+	}
+	return targets, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+)
+
+// DiffWords return new text with removed words marked as [-word-] and added words as {+word+}
+func DiffWords(oldText, newText string) string {
+	a, b := strings.Fields(oldText), strings.Fields(newText)
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var words []string
+	var removed, added []string
+	flush := func() {
+		if len(removed) > 0 {
+			words = append(words, "[-"+strings.Join(removed, " ")+"-]")
+			removed = nil
+		}
+		if len(added) > 0 {
+			words = append(words, "{+"+strings.Join(added, " ")+"+}")
+			added = nil
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			flush()
+			words = append(words, a[i])
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			removed = append(removed, a[i])
+			i++
+		default:
+			added = append(added, b[j])
+			j++
+		}
+	}
+	flush()
+	return strings.Join(words, " ")
+}
@@ -15,12 +15,27 @@ import (
 
 // AddMissedCategories perform add missed categories
 func AddMissedCategories(ctx context.Context, items []*gofeed.Item) (map[string]int, error) {
-	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
-	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
 	categories := funk.FlatMap(items, func(v *gofeed.Item) []string {
 		return v.Categories
 	}).([]string)
-	categories = funk.Uniq(categories).([]string)
+	return addCategories(ctx, funk.Uniq(categories).([]string))
+}
+
+// AddItemCategories perform adding of the missed categories of item and set their ids
+func AddItemCategories(ctx context.Context, item *config.FeedItem) error {
+	categoriesMap, err := addCategories(ctx, item.Categories)
+	if err != nil {
+		return err
+	}
+	item.CategoriesIDs = funk.Map(item.Categories, func(category string) int {
+		return categoriesMap[category]
+	}).([]int)
+	return nil
+}
+
+func addCategories(ctx context.Context, categories []string) (map[string]int, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
 	categoriesMap := make(map[string]int)
 	for _, categoryName := range categories {
 		var category entity.Category
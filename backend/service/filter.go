@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/misc"
+)
+
+type filterRule struct {
+	rule   *entity.FilterRule
+	regexp *regexp.Regexp
+}
+
+// Filter is a set of compiled filter rules
+type Filter struct {
+	rules []filterRule
+}
+
+// CompileFilterRule return regexp of a filter rule
+func CompileFilterRule(rule *entity.FilterRule) (*regexp.Regexp, error) {
+	var pattern string
+	switch rule.Match {
+	case "", entity.FilterMatchSubstring:
+		pattern = regexp.QuoteMeta(rule.Pattern)
+	case entity.FilterMatchWord:
+		pattern = `(?:^|[^\p{L}\p{N}_])` + regexp.QuoteMeta(rule.Pattern) + `(?:$|[^\p{L}\p{N}_])`
+	case entity.FilterMatchExact:
+		pattern = `^` + regexp.QuoteMeta(rule.Pattern) + `$`
+	case entity.FilterMatchRegex:
+		pattern = rule.Pattern
+	default:
+		return nil, fmt.Errorf("unknown match type '%s' of filter rule '%d'", rule.Match, rule.ID)
+	}
+	if rule.Pattern == "" {
+		return nil, fmt.Errorf("empty pattern of filter rule '%d'", rule.ID)
+	}
+	if !rule.CaseSensitive {
+		pattern = `(?i)` + pattern
+	}
+	r, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile filter rule '%d': %v", rule.ID, err)
+	}
+	return r, nil
+}
+
+// NewFilter return filter by rules, rules are ordered by priority and an allow rule wins over a block rule of the same priority
+func NewFilter(rules []*entity.FilterRule) *Filter {
+	filter := &Filter{}
+	for _, rule := range rules {
+		r, err := CompileFilterRule(rule)
+		if err != nil {
+			misc.Error("compile_filter_rule", fmt.Sprintf("compile filter rule '%d'", rule.ID), err)
+			continue
+		}
+		filter.rules = append(filter.rules, filterRule{rule: rule, regexp: r})
+	}
+	sort.SliceStable(filter.rules, func(i, j int) bool {
+		a, b := filter.rules[i].rule, filter.rules[j].rule
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.Action == entity.FilterActionAllow && b.Action != entity.FilterActionAllow
+	})
+	return filter
+}
+
+func filterValues(field string, item *config.FeedItem) []string {
+	switch field {
+	case entity.FilterFieldTitle:
+		return []string{item.Title}
+	case entity.FilterFieldDescription:
+		return []string{item.Description}
+	case entity.FilterFieldText:
+		return []string{item.Title, item.Description}
+	case entity.FilterFieldLink:
+		return []string{item.Link}
+	case entity.FilterFieldCategory:
+		return item.Categories
+	case entity.FilterFieldAuthor:
+		return []string{item.Author}
+	}
+	return append([]string{item.Title, item.Description, item.Link, item.Author}, item.Categories...)
+}
+
+// Match return the rule deciding about item or nil if no rule is matched
+func (f *Filter) Match(item *config.FeedItem) *entity.FilterRule {
+	for _, rule := range f.rules {
+		for _, value := range filterValues(rule.rule.Field, item) {
+			if value != "" && rule.regexp.MatchString(value) {
+				return rule.rule
+			}
+		}
+	}
+	return nil
+}
+
+// Decide return true if item passes the filter, the decision of the matched rule is traced and the hit of the rule
+// is counted only the first time it decides about item, so the hits don't grow with the polls; scope is added
+// to the reason of the decision
+func (f *Filter) Decide(ctx context.Context, item *config.FeedItem, scope string) bool {
+	rule := f.Match(item)
+	if rule == nil {
+		return true
+	}
+	allowed := rule.Action == entity.FilterActionAllow
+	decision, verb := entity.DecisionRejected, "blocked"
+	if allowed {
+		decision, verb = entity.DecisionPassed, "allowed"
+	}
+	first := Trace(ctx, item, entity.StageFilter, decision, fmt.Sprintf("%s%s by %s", verb, scope, DescribeFilterRule(rule)))
+	if first && rule.ID != 0 {
+		if err := entity.CountFilterRuleHit(ctx, rule.ID); err != nil {
+			misc.Error("count_filter_rule_hit", fmt.Sprintf("count hit of filter rule '%d'", rule.ID), err)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"estonia-news/entity"
+)
+
+var placeholderRegexp = regexp.MustCompile(`⟦\s*(\d+)\s*⟧`)
+
+type glossaryTranslator struct {
+	translator Translator
+}
+
+// NewGlossaryTranslator return translator replacing glossary terms by placeholders before translation
+// and restoring them with their translations afterwards
+func NewGlossaryTranslator(translator Translator) Translator {
+	return &glossaryTranslator{translator: translator}
+}
+
+func (t *glossaryTranslator) Translate(ctx context.Context, text, from, to string) (string, error) {
+	terms, err := entity.GetGlossaryTerms(ctx, from, to)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	protected, values := ProtectTerms(text, terms)
+	translation, err := t.translator.Translate(ctx, protected, from, to)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	return RestoreTerms(translation, values), nil
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// ProtectTerms return text with whole word glossary terms replaced by numbered placeholders
+// and the values to restore, the longest terms are replaced first
+func ProtectTerms(text string, terms []*entity.GlossaryTerm) (string, []string) {
+	sorted := make([]*entity.GlossaryTerm, len(terms))
+	copy(sorted, terms)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return utf8.RuneCountInString(sorted[i].Term) > utf8.RuneCountInString(sorted[j].Term)
+	})
+	var values []string
+	for _, term := range sorted {
+		if term.Term == "" {
+			continue
+		}
+		var result strings.Builder
+		// written is the end of the part of text copied to result, offset is where the next match is searched
+		written, offset := 0, 0
+		for {
+			index := strings.Index(text[offset:], term.Term)
+			if index < 0 {
+				break
+			}
+			start := offset + index
+			end := start + len(term.Term)
+			before, _ := utf8.DecodeLastRuneInString(text[:start])
+			after, _ := utf8.DecodeRuneInString(text[end:])
+			if (start > 0 && isWordRune(before)) || (end < len(text) && isWordRune(after)) {
+				_, size := utf8.DecodeRuneInString(text[start:])
+				offset = start + size
+				continue
+			}
+			value := term.Translation
+			if value == "" {
+				value = term.Term
+			}
+			result.WriteString(text[written:start])
+			result.WriteString(fmt.Sprintf("⟦%d⟧", len(values)))
+			values = append(values, value)
+			written, offset = end, end
+		}
+		result.WriteString(text[written:])
+		text = result.String()
+	}
+	return text, values
+}
+
+// RestoreTerms return text with placeholders replaced by values
+func RestoreTerms(text string, values []string) string {
+	return placeholderRegexp.ReplaceAllStringFunc(text, func(placeholder string) string {
+		index, err := strconv.Atoi(placeholderRegexp.FindStringSubmatch(placeholder)[1])
+		if err != nil || index >= len(values) {
+			return placeholder
+		}
+		return values[index]
+	})
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"estonia-news/entity"
+
+	"github.com/mmcdole/gofeed"
+)
+
+var formattedGUIDRegexp = regexp.MustCompile(`^\w+#\d+$`)
+
+var prefixRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CanonicalURL return link without fragment, tracking params and trailing slash
+func CanonicalURL(link string) string {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || parsed.Host == "" {
+		return strings.TrimSpace(link)
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	query := parsed.Query()
+	for key := range query {
+		lowerKey := strings.ToLower(key)
+		if strings.HasPrefix(lowerKey, "utm_") || lowerKey == "fbclid" || lowerKey == "gclid" {
+			query.Del(key)
+		}
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	parsed.RawQuery = strings.Join(parts, "&")
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = ""
+	return parsed.String()
+}
+
+func guidRuleValues(rule *entity.GUIDRule, item *gofeed.Item) []string {
+	switch rule.Field {
+	case entity.GUIDFieldGUID:
+		return []string{item.GUID}
+	case entity.GUIDFieldLink:
+		return []string{item.Link}
+	case entity.GUIDFieldQuery:
+		values := []string{}
+		for _, link := range []string{item.GUID, item.Link} {
+			if parsed, err := url.Parse(link); err == nil {
+				values = append(values, parsed.Query().Get(rule.Param))
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// ExtractGUID return GUID of item by rules of provider, the canonical link is hashed if no rule is matched
+func ExtractGUID(provider *entity.Provider, item *gofeed.Item) (string, error) {
+	if formattedGUIDRegexp.MatchString(item.GUID) {
+		return item.GUID, nil
+	}
+	for _, rule := range provider.GUIDRules {
+		r, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return "", fmt.Errorf("failed to compile GUID rule '%d': %v", rule.ID, err)
+		}
+		for _, value := range guidRuleValues(rule, item) {
+			if value == "" {
+				continue
+			}
+			if match := r.FindStringSubmatch(value); len(match) > 1 && match[1] != "" {
+				return fmt.Sprintf("%s#%s", rule.Prefix, match[1]), nil
+			}
+		}
+	}
+	link := item.Link
+	if link == "" {
+		link = item.GUID
+	}
+	if link == "" {
+		return "", errors.New("empty GUID")
+	}
+	prefix := prefixRegexp.ReplaceAllString(strings.ToLower(provider.Name), "")
+	if prefix == "" {
+		prefix = "url"
+	}
+	hash := sha256.Sum256([]byte(CanonicalURL(link)))
+	return fmt.Sprintf("%s#%s", prefix, hex.EncodeToString(hash[:8])), nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// HoldItem put a new item on hold until the hold window passes, the held item is refreshed by the next polls,
+// false is returned when the item is published already
+func HoldItem(ctx context.Context, item *config.FeedItem) (bool, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	feedTitle := ctx.Value(config.CtxFeedTitleKey).(string)
+	published, err := dbConnect.NewSelect().Model(&entity.Entry{}).Where("id = ?", item.GUID).Exists(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to hold item '%s': %v", item.GUID, err)
+	}
+	if published {
+		return false, nil
+	}
+	_, err = dbConnect.NewInsert().Model(&entity.PendingItem{
+		EntryID:    item.GUID,
+		ProviderID: provider.ID,
+		Title:      item.Title,
+		FeedTitle:  feedTitle,
+		Item:       item,
+		ReleaseAt:  time.Now().Add(config.HoldWindow),
+		CreatedAt:  time.Now(),
+	}).On("CONFLICT (entry_id) DO UPDATE").Set("title = EXCLUDED.title, feed_title = EXCLUDED.feed_title, item = EXCLUDED.item").Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to hold item '%s': %v", item.GUID, err)
+	}
+	return true, nil
+}
+
+// GetReleasedItems return held items whose hold window has passed
+func GetReleasedItems(ctx context.Context) ([]*entity.PendingItem, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var items []*entity.PendingItem
+	err := dbConnect.NewSelect().Model(&items).Relation("Provider").
+		Where("pi.release_at <= ?", time.Now()).
+		Order("pi.release_at").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get released items: %v", err)
+	}
+	return items, nil
+}
+
+// GetSimilarHeldItems return held items of other providers of the same language similar to item, including the item
+func GetSimilarHeldItems(ctx context.Context, item *entity.PendingItem) ([]*entity.PendingItem, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var items []*entity.PendingItem
+	err := dbConnect.NewSelect().Model(&items).Relation("Provider").
+		Where("provider.lang = ?", item.Provider.Lang).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("pi.entry_id = ?", item.EntryID).
+				WhereOr("pi.provider_id != ? AND similarity(?, pi.title) > ?", item.ProviderID, item.Title, config.SimilarityThreshold)
+		}).
+		Order("pi.created_at").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get held items similar to '%s': %v", item.EntryID, err)
+	}
+	return items, nil
+}
+
+// DeleteHeldItems perform delete of held items
+func DeleteHeldItems(ctx context.Context, items []*entity.PendingItem) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.EntryID)
+	}
+	_, err := dbConnect.NewDelete().Model(&entity.PendingItem{}).Where("entry_id IN (?)", bun.In(ids)).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete held items: %v", err)
+	}
+	return nil
+}
+
+// ChooseBestItem return the version of a story to publish: by provider priority,
+// then without paywall, then with image, then with the longest description
+func ChooseBestItem(items []*entity.PendingItem) *entity.PendingItem {
+	if len(items) == 0 {
+		return nil
+	}
+	sorted := make([]*entity.PendingItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Provider.Priority != b.Provider.Priority {
+			return a.Provider.Priority > b.Provider.Priority
+		}
+		if a.Item.Paywall != b.Item.Paywall {
+			return !a.Item.Paywall
+		}
+		if (a.Item.ImageURL != "") != (b.Item.ImageURL != "") {
+			return a.Item.ImageURL != ""
+		}
+		return len([]rune(a.Item.Description)) > len([]rune(b.Item.Description))
+	})
+	return sorted[0]
+}
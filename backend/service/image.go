@@ -0,0 +1,220 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decoding of gif images
+	"image/jpeg"
+	_ "image/png" // decoding of png images
+	"net/http"
+	"slices"
+	"sync"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/misc"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/uptrace/bun"
+)
+
+// ErrUnusableImage is returned when an image can't be posted as a photo
+var ErrUnusableImage = errors.New("unusable image")
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+// unusableImages is reasons of image URLs found unusable, they are not downloaded again
+var unusableImages sync.Map
+
+// usableImages is image URLs found usable, they are not checked again
+var usableImages sync.Map
+
+// preparedImages keep the last prepared images to upload them without downloading again
+var preparedImages = &imageCache{items: map[string][]byte{}}
+
+type imageCache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+	order []string
+}
+
+func (c *imageCache) get(imageURL string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	content, ok := c.items[imageURL]
+	return content, ok
+}
+
+func (c *imageCache) put(imageURL string, content []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[imageURL]; ok {
+		return
+	}
+	if len(c.order) >= config.ImageCacheSize {
+		delete(c.items, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.items[imageURL] = content
+	c.order = append(c.order, imageURL)
+}
+
+// loadImage return image of URL prepared for upload, an unusable image is remembered
+func loadImage(imageURL string) ([]byte, error) {
+	if reason, ok := unusableImages.Load(imageURL); ok {
+		return nil, reason.(error)
+	}
+	if content, ok := preparedImages.get(imageURL); ok {
+		return content, nil
+	}
+	content, err := getImage(imageURL)
+	if err != nil {
+		return nil, err
+	}
+	content, err = PrepareImage(content)
+	if err != nil {
+		unusableImages.Store(imageURL, err)
+		return nil, err
+	}
+	usableImages.Store(imageURL, true)
+	preparedImages.put(imageURL, content)
+	return content, nil
+}
+
+// scaleImage return img downscaled to width x height by averaging of the source pixels, transparent pixels are put on white
+func scaleImage(img image.Image, width, height int) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := range width {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+			white := 0xffff - a/n
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r/n + white) >> 8),
+				G: uint8((g/n + white) >> 8),
+				B: uint8((b/n + white) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+// PrepareImage return image fit to be uploaded as a photo: the type, size and dimensions are checked,
+// an oversized or not jpeg/png image is downscaled and re-encoded to jpeg
+func PrepareImage(body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return nil, fmt.Errorf("%w: empty", ErrUnusableImage)
+	}
+	contentType := http.DetectContentType(body)
+	if !slices.Contains(imageTypes, contentType) {
+		return nil, fmt.Errorf("%w: unsupported type %s", ErrUnusableImage, contentType)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnusableImage, err)
+	}
+	if cfg.Width < config.ImageMinWidth || cfg.Height < config.ImageMinHeight {
+		return nil, fmt.Errorf("%w: too small %dx%d", ErrUnusableImage, cfg.Width, cfg.Height)
+	}
+	if cfg.Width*cfg.Height > config.ImageMaxPixels || max(cfg.Width, cfg.Height) > 20*min(cfg.Width, cfg.Height) {
+		return nil, fmt.Errorf("%w: unsupported dimensions %dx%d", ErrUnusableImage, cfg.Width, cfg.Height)
+	}
+	if contentType != "image/gif" && len(body) <= config.ImageMaxSize && max(cfg.Width, cfg.Height) <= config.ImageMaxDimension {
+		return body, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnusableImage, err)
+	}
+	scale := min(1, float64(config.ImageMaxDimension)/float64(max(cfg.Width, cfg.Height)))
+	for {
+		width, height := max(1, int(float64(cfg.Width)*scale)), max(1, int(float64(cfg.Height)*scale))
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, scaleImage(img, width, height), &jpeg.Options{Quality: config.ImageQuality}); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnusableImage, err)
+		}
+		if buf.Len() <= config.ImageMaxSize {
+			return buf.Bytes(), nil
+		}
+		scale *= 0.75
+	}
+}
+
+// getImageFileID return Telegram file id of an already uploaded image, empty if the image is not uploaded yet
+func getImageFileID(ctx context.Context, imageURL string) (string, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var fileIDs []string
+	err := dbConnect.NewSelect().Model((*entity.Entry)(nil)).Column("image_file_id").
+		Where("image_url = ? AND image_file_id IS NOT NULL", imageURL).
+		Order("updated_at DESC").Limit(1).Scan(ctx, &fileIDs)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file id of image '%s': %v", imageURL, err)
+	}
+	if len(fileIDs) == 0 {
+		return "", nil
+	}
+	return fileIDs[0], nil
+}
+
+// getPhoto return photo file of image URL, the uploaded file is reused by its Telegram file id
+func getPhoto(ctx context.Context, imageURL string) (tgbotapi.RequestFileData, error) {
+	fileID, err := getImageFileID(ctx, imageURL)
+	if err != nil {
+		return nil, err
+	}
+	if fileID != "" {
+		return tgbotapi.FileID(fileID), nil
+	}
+	content, err := loadImage(imageURL)
+	if err != nil {
+		return nil, err
+	}
+	return tgbotapi.FileBytes{Name: imageURL, Bytes: content}, nil
+}
+
+// CheckImage perform drop of the image of item if it can't be posted as a photo, the item is posted as a text
+// message then and the reason of the drop is returned, the image is kept if it can't be downloaded for now
+func CheckImage(ctx context.Context, item *config.FeedItem) error {
+	if item.ImageURL == "" {
+		return nil
+	}
+	if _, ok := usableImages.Load(item.ImageURL); ok {
+		return nil
+	}
+	fileID, err := getImageFileID(ctx, item.ImageURL)
+	if err != nil || fileID != "" {
+		return err
+	}
+	_, err = loadImage(item.ImageURL)
+	if errors.Is(err, ErrUnusableImage) {
+		item.ImageURL = ""
+		return err
+	}
+	if err != nil {
+		misc.Error("check_image", fmt.Sprintf("check image '%s'", item.ImageURL), err)
+	}
+	return nil
+}
+
+// GetPhotoFileID return Telegram file id of the biggest size of the photo of a sent message
+func GetPhotoFileID(msg *tgbotapi.Message) string {
+	if len(msg.Photo) == 0 {
+		return ""
+	}
+	return msg.Photo[len(msg.Photo)-1].FileID
+}
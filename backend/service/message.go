@@ -2,19 +2,84 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
 	"regexp"
 	"strings"
+	"time"
 
 	"estonia-news/config"
 	"estonia-news/entity"
 	"estonia-news/misc"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/thoas/go-funk"
+	"github.com/uptrace/bun"
 )
 
-func formatText(msg *Message) string {
-	return fmt.Sprintf("<b>%s</b>\n\n%s", msg.Title, msg.Description)
+func getTemplateData(ctx context.Context, msg *Message) *TemplateData {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	return &TemplateData{
+		Title:       msg.Title,
+		Description: msg.Description,
+		Categories:  msg.Categories,
+		Topics:      msg.Topics,
+		Hashtags:    msg.Hashtags,
+		Provider:    provider.Name,
+		FeedTitle:   msg.FeedTitle,
+		Lang:        provider.Lang,
+		Published:   msg.Published,
+		Paywall:     msg.Paywall,
+		Link:        msg.Link,
+	}
+}
+
+func formatText(ctx context.Context, msg *Message) string {
+	text := RenderTemplate(ctx, entity.TemplateText, getTemplateData(ctx, msg))
+	if len(msg.Sources) > 0 {
+		links := funk.Map(msg.Sources, func(source *entity.StorySource) string {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(source.Link), html.EscapeString(source.Provider.Name))
+		}).([]string)
+		text += fmt.Sprintf("\n\n%s %s", msg.SourcesLabel, strings.Join(links, ", "))
+	}
+	if msg.Retracted {
+		text = fmt.Sprintf("<s>%s</s>\n\n%s", text, msg.RetractedLabel)
+	}
+	if !msg.UpdatedAt.IsZero() {
+		text += fmt.Sprintf("\n\n%s %s", msg.UpdatedLabel, msg.UpdatedAt.In(config.TimeZone).Format("15:04"))
+	}
+	return text
+}
+
+func getSourcesLabel(lang string) string {
+	switch lang {
+	case "EST":
+		return "Samal teemal:"
+	case "RUS":
+		return "Также сообщают:"
+	}
+	return "Also reported by:"
+}
+
+func getRetractedLabel(lang string) string {
+	switch lang {
+	case "EST":
+		return "⚠️ Artikkel on eemaldatud"
+	case "RUS":
+		return "⚠️ Статья удалена"
+	}
+	return "⚠️ Article withdrawn"
+}
+
+func getUpdatedLabel(lang string) string {
+	switch lang {
+	case "EST":
+		return "✏️ muudetud"
+	case "RUS":
+		return "✏️ обновлено"
+	}
+	return "✏️ updated"
 }
 
 // CleanUpText return formated test
@@ -31,98 +96,182 @@ func CleanUpText(text string) string {
 func getText(ctx context.Context, msg *Message) string {
 	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
 	translateLang := ctx.Value(config.CtxTranslateLangKey).(string)
-	msg.Title = CleanUpText(msg.Title)
-	msg.Description = CleanUpText(msg.Description)
-	if provider.Lang == "EST" && translateLang == "ENG" {
-		if msg.Title != "" {
-			text, err := translate(msg.Title, "et", "en")
-			if err != nil {
-				misc.Fatal("get_translate", "get translate", err)
-			}
-			msg.Title = text
-		}
-		if msg.Description != "" {
-			text, err := translate(msg.Description, "et", "en")
-			if err != nil {
-				misc.Fatal("get_translate", "get translate", err)
-			}
-			msg.Description = text
-		}
+	msg.Title = SanitizeHTML(CleanUpText(msg.Title))
+	msg.Description = SanitizeHTML(CleanUpText(msg.Description))
+	lang := provider.Lang
+	if translateLang != "" && !strings.EqualFold(translateLang, provider.Lang) {
+		// the translation is sanitized again as the translator may break the markup
+		msg.Title = SanitizeHTML(translateText(ctx, msg.Title, provider.Lang, translateLang))
+		msg.Description = SanitizeHTML(translateText(ctx, msg.Description, provider.Lang, translateLang))
+		lang = strings.ToUpper(translateLang)
 	}
-	return formatText(msg)
+	msg.SourcesLabel = getSourcesLabel(lang)
+	msg.UpdatedLabel = getUpdatedLabel(lang)
+	msg.RetractedLabel = getRetractedLabel(lang)
+	return formatText(ctx, msg)
 }
 
-func getButton(ctx context.Context, msg *Message) *tgbotapi.InlineKeyboardMarkup {
-	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
-	link := msg.Link
-	readOnText := "Read on"
-	switch provider.Lang {
-	case "EST":
-		readOnText = "Loe edasi"
-	case "RUS":
-		readOnText = "Читать на"
+// translateText return translated text, the original text is returned if translation failed
+func translateText(ctx context.Context, text, from, to string) string {
+	if text == "" {
+		return text
+	}
+	translator, ok := ctx.Value(config.CtxTranslatorKey).(Translator)
+	if !ok {
+		translator = NewGoogleTranslator("")
 	}
-	name := fmt.Sprintf("%s %s", readOnText, provider.Name)
-	if msg.Paywall {
-		name = "💰" + name
+	translation, err := translator.Translate(ctx, text, LanguageCode(from), LanguageCode(to))
+	if err != nil {
+		misc.Error("get_translate", "get translate", err)
+		return text
 	}
-	button := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(name, link)))
+	return translation
+}
+
+func getButton(ctx context.Context, msg *Message) *tgbotapi.InlineKeyboardMarkup {
+	name := RenderTemplate(ctx, entity.TemplateButton, getTemplateData(ctx, msg))
+	button := tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(name, msg.Link)))
 	return &button
 }
 
+// fitText return text of message shortened to limit, the description is truncated first to keep the rest of the template
+func fitText(ctx context.Context, msg *Message, text string, limit int) string {
+	overflow := VisibleLength(text) - limit
+	if overflow <= 0 {
+		return text
+	}
+	truncated := *msg
+	truncated.Description = TruncateHTML(msg.Description, max(VisibleLength(msg.Description)-overflow, 0))
+	return TruncateHTML(formatText(ctx, &truncated), limit)
+}
+
+// getImagePreview return invisible link to the image shown as the link preview of a text message
+func getImagePreview(imageURL string) string {
+	return fmt.Sprintf(`<a href="%s">&#8203;</a>`, html.EscapeString(imageURL))
+}
+
+// getMessageType return photo if the image and the text fit a photo post and text otherwise
+func getMessageType(msg *Message, text string) string {
+	if msg.ImageURL == "" || VisibleLength(text) > config.CaptionLimit {
+		return entity.MessageTypeText
+	}
+	return entity.MessageTypePhoto
+}
+
+// getMessageText return text of a text post, the image is shown as the link preview
+func getMessageText(ctx context.Context, msg *Message, text string) string {
+	if msg.ImageURL == "" {
+		return fitText(ctx, msg, text, config.MessageLimit)
+	}
+	return getImagePreview(msg.ImageURL) + fitText(ctx, msg, text, config.MessageLimit-1)
+}
+
 func createMessageObject(ctx context.Context, msg *Message) (tgbotapi.Chattable, error) {
 	text := getText(ctx, msg)
 	button := getButton(ctx, msg)
-	chatID := ctx.Value(config.CtxChatIDKey).(int64)
-	var obj tgbotapi.Chattable
-	if msg.ImageURL == "" {
-		obj = tgbotapi.MessageConfig{
-			BaseChat:              tgbotapi.BaseChat{ChatID: chatID, ReplyMarkup: button},
-			Text:                  text,
-			ParseMode:             tgbotapi.ModeHTML,
-			DisableWebPagePreview: true,
-		}
-	} else {
-		content, err := getImage(msg.ImageURL)
-		if err != nil {
+	chatID := msg.ChatID
+	var photo tgbotapi.RequestFileData
+	if getMessageType(msg, text) == entity.MessageTypePhoto {
+		var err error
+		photo, err = getPhoto(ctx, msg.ImageURL)
+		if errors.Is(err, ErrUnusableImage) {
+			// the text is kept, only the type of the message is switched
+			misc.Error("get_photo", fmt.Sprintf("get photo '%s'", msg.ImageURL), err)
+			msg.ImageURL = ""
+		} else if err != nil {
 			return nil, err
 		}
-		file := tgbotapi.FileBytes{Name: msg.ImageURL, Bytes: content}
-		chatID := ctx.Value(config.CtxChatIDKey).(int64)
-		obj = tgbotapi.PhotoConfig{
-			BaseFile: tgbotapi.BaseFile{
-				BaseChat: tgbotapi.BaseChat{ChatID: chatID, ReplyMarkup: button},
-				File:     file,
-			},
-			Caption:   text,
-			ParseMode: tgbotapi.ModeHTML,
-		}
-
 	}
-	return obj, nil
+	if photo == nil {
+		return tgbotapi.MessageConfig{
+			BaseChat:              tgbotapi.BaseChat{ChatID: chatID, ReplyMarkup: button},
+			Text:                  getMessageText(ctx, msg, text),
+			ParseMode:             tgbotapi.ModeHTML,
+			DisableWebPagePreview: msg.ImageURL == "",
+		}, nil
+	}
+	return tgbotapi.PhotoConfig{
+		BaseFile: tgbotapi.BaseFile{
+			BaseChat: tgbotapi.BaseChat{ChatID: chatID, ReplyMarkup: button},
+			File:     photo,
+		},
+		Caption:   text,
+		ParseMode: tgbotapi.ModeHTML,
+	}, nil
 }
 
-func editMessageObject(ctx context.Context, messageID int, msg *Message) *tgbotapi.EditMessageCaptionConfig {
+// editMessageObject return edit of text, caption or media of the message of entry in a channel,
+// ErrRepostRequired is returned if the message has to change its type
+func editMessageObject(ctx context.Context, entry entity.Entry, message *entity.EntryMessage, msg *Message) (tgbotapi.Chattable, error) {
 	text := getText(ctx, msg)
 	button := getButton(ctx, msg)
-	chatID := ctx.Value(config.CtxChatIDKey).(int64)
-	return &tgbotapi.EditMessageCaptionConfig{
-		BaseEdit: tgbotapi.BaseEdit{
-			ChatID:      chatID,
-			MessageID:   messageID,
-			ReplyMarkup: button,
-		},
-		Caption:   text,
-		ParseMode: tgbotapi.ModeHTML,
+	if msg.ChatID != message.ChatID {
+		return nil, fmt.Errorf("%w: chat %d to %d", ErrRepostRequired, message.ChatID, msg.ChatID)
+	}
+	messageType := getMessageType(msg, text)
+	if msg.Retracted && msg.ImageURL != "" && message.MessageType == entity.MessageTypePhoto {
+		// the label of a retracted post never turns a photo into a text post, the caption is shortened instead
+		messageType = entity.MessageTypePhoto
+	}
+	if messageType != message.MessageType {
+		return nil, fmt.Errorf("%w: %s to %s", ErrRepostRequired, message.MessageType, messageType)
 	}
+	baseEdit := tgbotapi.BaseEdit{
+		ChatID:      message.ChatID,
+		MessageID:   message.MessageID,
+		ReplyMarkup: button,
+	}
+	switch {
+	case messageType == entity.MessageTypeText:
+		return tgbotapi.EditMessageTextConfig{
+			BaseEdit:              baseEdit,
+			Text:                  getMessageText(ctx, msg, text),
+			ParseMode:             tgbotapi.ModeHTML,
+			DisableWebPagePreview: msg.ImageURL == "",
+		}, nil
+	case entry.ImageURL != msg.ImageURL:
+		photo, err := getPhoto(ctx, msg.ImageURL)
+		if err != nil {
+			return nil, err
+		}
+		media := tgbotapi.NewInputMediaPhoto(photo)
+		media.Caption = fitText(ctx, msg, text, config.CaptionLimit)
+		media.ParseMode = tgbotapi.ModeHTML
+		return tgbotapi.EditMessageMediaConfig{
+			BaseEdit: baseEdit,
+			Media:    media,
+		}, nil
+	}
+	return tgbotapi.EditMessageCaptionConfig{
+		BaseEdit:  baseEdit,
+		Caption:   fitText(ctx, msg, text, config.CaptionLimit),
+		ParseMode: tgbotapi.ModeHTML,
+	}, nil
 }
 
-func deleteMessageObject(ctx context.Context, messageID int) *tgbotapi.DeleteMessageConfig {
-	chatID := ctx.Value(config.CtxChatIDKey).(int64)
+func deleteMessageObject(message *entity.EntryMessage) *tgbotapi.DeleteMessageConfig {
 	return &tgbotapi.DeleteMessageConfig{
-		ChatID:    chatID,
-		MessageID: messageID,
+		ChatID:    message.ChatID,
+		MessageID: message.MessageID,
+	}
+}
+
+func parsePublished(published string) time.Time {
+	pubDate, _ := time.Parse(time.RFC1123Z, published)
+	return pubDate
+}
+
+// setTopics set topics and hashtags of the categories to message
+func setTopics(ctx context.Context, msg *Message, categoriesIDs []int) error {
+	topics, err := entity.GetTopicsByCategories(ctx, categoriesIDs)
+	if err != nil {
+		return err
 	}
+	for _, topic := range topics {
+		msg.Topics = append(msg.Topics, topic.Name)
+		msg.Hashtags = append(msg.Hashtags, topic.Hashtag)
+	}
+	return nil
 }
 
 // Message - config
@@ -131,48 +280,158 @@ type Message struct {
 	Title       string
 	Description string
 	Categories  []string
+	Topics      []string
+	Hashtags    []string
 	Link        string
 	ImageURL    string
+	Published   time.Time
 	Paywall     bool
+	ChatID      int64
+
+	Sources      []*entity.StorySource
+	SourcesLabel string
+	UpdatedAt    time.Time
+	UpdatedLabel string
+
+	Retracted      bool
+	RetractedLabel string
 }
 
 // Add is add message
 func Add(ctx context.Context, item *config.FeedItem) (tgbotapi.Chattable, error) {
 	feedTitle := ctx.Value(config.CtxFeedTitleKey).(string)
-	msg, err := createMessageObject(ctx, &Message{
+	sources, err := GetStorySources(ctx, item.GUID)
+	if err != nil {
+		return nil, err
+	}
+	message := &Message{
 		FeedTitle:   feedTitle,
 		Title:       item.Title,
 		Description: item.Description,
 		Categories:  item.Categories,
 		Link:        item.Link,
 		ImageURL:    item.ImageURL,
+		Published:   parsePublished(item.Published),
 		Paywall:     item.Paywall,
-	})
+		ChatID:      GetChatID(ctx, item.Paywall),
+		Sources:     sources,
+	}
+	if err := setTopics(ctx, message, item.CategoriesIDs); err != nil {
+		return nil, err
+	}
+	msg, err := createMessageObject(ctx, message)
 	if err != nil {
-		misc.Fatal("get_message", "get message", err)
+		return nil, fmt.Errorf("failed to create message for record '%s': %v", item.GUID, err)
 	}
+	item.ImageURL = message.ImageURL
 	return msg, nil
 }
 
-// Edit is edit message
-func Edit(ctx context.Context, item *config.FeedItem, entry entity.Entry) (*tgbotapi.EditMessageCaptionConfig, error) {
+// ErrRepostRequired is returned when a message can't be edited to the new content and has to be sent again
+var ErrRepostRequired = errors.New("repost required")
+
+// GetMessageType return type of a sent message
+func GetMessageType(msg *tgbotapi.Message) string {
+	if len(msg.Photo) > 0 {
+		return entity.MessageTypePhoto
+	}
+	return entity.MessageTypeText
+}
+
+// getUpdatedAt return time of the last change of title or description of entry, zero time if it is not changed
+func getUpdatedAt(ctx context.Context, item *config.FeedItem, entry entity.Entry) (time.Time, error) {
+	if NormalizeText(item.Title) != NormalizeText(entry.Title) || NormalizeText(item.Description) != NormalizeText(entry.Description) {
+		return time.Now(), nil
+	}
+	revisions, err := entity.GetEntryRevisions(ctx, entry.ID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for i := len(revisions) - 1; i > 0; i-- {
+		if NormalizeText(revisions[i].Title) != NormalizeText(revisions[i-1].Title) || NormalizeText(revisions[i].Description) != NormalizeText(revisions[i-1].Description) {
+			return revisions[i].CreatedAt, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// Edit is edit message of entry in a channel
+func Edit(ctx context.Context, item *config.FeedItem, entry entity.Entry, entryMessage *entity.EntryMessage) (tgbotapi.Chattable, error) {
 	feedTitle := ctx.Value(config.CtxFeedTitleKey).(string)
-	msg := editMessageObject(ctx, entry.MessageID, &Message{
+	sources, err := GetStorySources(ctx, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	message := &Message{
 		FeedTitle:   feedTitle,
 		Title:       item.Title,
 		Description: item.Description,
 		Categories:  item.Categories,
 		Link:        item.Link,
 		ImageURL:    item.ImageURL,
+		Published:   parsePublished(item.Published),
 		Paywall:     item.Paywall,
-	})
+		ChatID:      GetChatID(ctx, item.Paywall),
+		Sources:     sources,
+	}
+	if err := setTopics(ctx, message, item.CategoriesIDs); err != nil {
+		return nil, err
+	}
+	if config.UpdatedMarker {
+		message.UpdatedAt, err = getUpdatedAt(ctx, item, entry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	msg, err := editMessageObject(ctx, entry, entryMessage, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit message for record '%s': %w", entry.ID, err)
+	}
+	return msg, nil
+}
+
+// Retract is edit message of entry in a channel to strike through the text and mark the article withdrawn
+func Retract(ctx context.Context, entry entity.Entry, entryMessage *entity.EntryMessage) (tgbotapi.Chattable, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	feedTitle := ctx.Value(config.CtxFeedTitleKey).(string)
+	var categories []entity.EntryToCategory
+	err := dbConnect.NewSelect().Model(&categories).Where("entry_id = ?", entry.ID).Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retract message for record '%s': %v", entry.ID, err)
+	}
+	sources, err := GetStorySources(ctx, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	message := &Message{
+		FeedTitle:   feedTitle,
+		Title:       entry.Title,
+		Description: entry.Description,
+		Link:        entry.Link,
+		ImageURL:    entry.ImageURL,
+		Published:   entry.PublishedAt,
+		Paywall:     entry.Paywall,
+		ChatID:      entryMessage.ChatID,
+		Sources:     sources,
+		Retracted:   true,
+	}
+	categoriesIDs := funk.Map(categories, func(category entity.EntryToCategory) int {
+		return category.CategoryID
+	}).([]int)
+	if err := setTopics(ctx, message, categoriesIDs); err != nil {
+		return nil, err
+	}
+	msg, err := editMessageObject(ctx, entry, entryMessage, message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retract message for record '%s': %w", entry.ID, err)
+	}
 	return msg, nil
 }
 
-// Delete is delete message
-func Delete(ctx context.Context, entry entity.Entry) error {
+// Delete is delete message of entry in a channel
+func Delete(ctx context.Context, entry entity.Entry, entryMessage *entity.EntryMessage) error {
 	bot := ctx.Value(config.CtxBotKey).(*tgbotapi.BotAPI)
-	msg := deleteMessageObject(ctx, entry.MessageID)
+	msg := deleteMessageObject(entryMessage)
 	_, err := bot.Request(msg)
 	if err != nil {
 		return fmt.Errorf("failed to delete Telegram message for entry '%s': %v", entry.ID, err)
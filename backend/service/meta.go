@@ -2,57 +2,192 @@ package service
 
 import (
 	"bytes"
-	"errors"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"slices"
 	"strings"
+	"time"
 
-	"github.com/lafin/http"
+	"estonia-news/config"
+	"estonia-news/entity"
+
+	"github.com/PuerkitoBio/goquery"
 	"github.com/thoas/go-funk"
-	"golang.org/x/net/html"
 )
 
-func findMeta(node *html.Node, key string) string {
-	if node.Type == html.ElementNode && node.Data == "meta" && len(node.Attr) > 0 {
-		if funk.Contains(node.Attr, func(attr html.Attribute) bool {
-			return attr.Key == "property" && attr.Val == key
-		}) {
-			found := funk.Find(node.Attr, func(attr html.Attribute) bool {
-				return attr.Key == "content"
-			})
-			return found.(html.Attribute).Val
-		}
-	}
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		found := findMeta(child, key)
-		if found != "" {
-			return found
-		}
-	}
-	return ""
+var articleTypes = []string{
+	"NewsArticle", "Article", "ReportageNewsArticle", "AnalysisNewsArticle",
+	"OpinionNewsArticle", "BackgroundNewsArticle", "ReviewNewsArticle", "BlogPosting",
 }
 
 // Meta is meta struct
 type Meta struct {
+	Title       string
 	ImageURL    string
 	Description string
+	Author      string
+	Section     string
+	Published   *time.Time
 	Paywall     bool
 }
 
+func findMeta(doc *goquery.Document, keys ...string) string {
+	for _, key := range keys {
+		for _, attr := range []string{"property", "name", "itemprop"} {
+			value := strings.TrimSpace(doc.Find(fmt.Sprintf(`meta[%s="%s"]`, attr, key)).First().AttrOr("content", ""))
+			if value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// jsonLDValue return text of a JSON-LD value which is a string, an object with name or url, or a list of them
+func jsonLDValue(value any) []string {
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			return []string{v}
+		}
+	case map[string]any:
+		for _, key := range []string{"name", "url", "@id"} {
+			if values := jsonLDValue(v[key]); len(values) > 0 {
+				return values
+			}
+		}
+	case []any:
+		var values []string
+		for _, item := range v {
+			values = append(values, jsonLDValue(item)...)
+		}
+		return values
+	}
+	return nil
+}
+
+func isArticleType(value any) bool {
+	return funk.Contains(jsonLDValue(value), func(t string) bool {
+		return slices.Contains(articleTypes, t)
+	})
+}
+
+func findArticle(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		if isArticleType(v["@type"]) {
+			return v
+		}
+		return findArticle(v["@graph"])
+	case []any:
+		for _, item := range v {
+			if article := findArticle(item); article != nil {
+				return article
+			}
+		}
+	}
+	return nil
+}
+
+func findJSONLDArticle(doc *goquery.Document) map[string]any {
+	var article map[string]any
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(s.Text())), &data); err != nil {
+			return true
+		}
+		article = findArticle(data)
+		return article == nil
+	})
+	return article
+}
+
+func firstValue(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func articleValue(article map[string]any, key string) string {
+	values := jsonLDValue(article[key])
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func resolveHTTPURL(base *url.URL, ref string) string {
+	link := resolveURL(base, ref)
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return ""
+	}
+	return link
+}
+
+// ParseMeta return meta info of the article page of link, JSON-LD article data is preferred over
+// Open Graph, Twitter and plain meta tags, paywall is detected by the paywall rules of the provider
+func ParseMeta(link string, body []byte, paywall *entity.PaywallRules) (*Meta, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse meta for link '%s': %v", link, err)
+	}
+	base, err := url.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse meta for link '%s': %v", link, err)
+	}
+	article := findJSONLDArticle(doc)
+	meta := Meta{
+		Title: firstValue(articleValue(article, "headline"), findMeta(doc, "og:title", "twitter:title"),
+			strings.TrimSpace(doc.Find("title").First().Text())),
+		Description: firstValue(articleValue(article, "description"), findMeta(doc, "og:description", "twitter:description", "description")),
+		Author: firstValue(strings.Join(jsonLDValue(article["author"]), ", "),
+			findMeta(doc, "article:author", "author", "twitter:creator")),
+		Section:   firstValue(articleValue(article, "articleSection"), findMeta(doc, "article:section")),
+		Published: parseW3CDate(firstValue(articleValue(article, "datePublished"), findMeta(doc, "article:published_time", "pubdate"))),
+	}
+	for _, image := range []string{
+		findMeta(doc, "og:image", "og:image:url", "og:image:secure_url"),
+		findMeta(doc, "twitter:image", "twitter:image:src"),
+		articleValue(article, "image"),
+	} {
+		if meta.ImageURL = resolveHTTPURL(base, image); meta.ImageURL != "" {
+			break
+		}
+	}
+	meta.Paywall = detectPaywall(doc, body, article, paywall)
+	return &meta, nil
+}
+
 // GetMeta return meta info by url
-func GetMeta(link string) (*Meta, error) {
-	var meta Meta
-	body, _, err := http.Get(link, nil)
+func GetMeta(link string, paywall *entity.PaywallRules) (*Meta, error) {
+	body, _, err := httpGet(link, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch meta for link '%s': %v", link, err)
 	}
-	doc, _ := html.Parse(bytes.NewReader(body))
-	meta.ImageURL = findMeta(doc, "og:image")
-	meta.Description = findMeta(doc, "og:description")
-	if meta.ImageURL == "" || meta.Description == "" {
-		return nil, errors.New("Meta is empty")
+	return ParseMeta(link, body, paywall)
+}
+
+// ApplyMeta fill item by meta info of its article, an item without image is posted as a text message
+func ApplyMeta(item *config.FeedItem, meta *Meta) {
+	item.Paywall = meta.Paywall
+	item.ImageURL = meta.ImageURL
+	if item.Title == "" {
+		item.Title = meta.Title
+	}
+	if item.Description == "" {
+		item.Description = meta.Description
+	}
+	if item.Author == "" {
+		item.Author = meta.Author
+	}
+	if item.Published == "" && meta.Published != nil {
+		item.Published = meta.Published.Format(time.RFC1123Z)
+	}
+	if len(item.Categories) == 0 && meta.Section != "" {
+		item.Categories = []string{meta.Section}
 	}
-	meta.Paywall = funk.Contains([]string{"fragment--teaser", `"isPremium":true`}, func(feature string) bool {
-		return strings.Contains(string(body), feature)
-	})
-	return &meta, nil
 }
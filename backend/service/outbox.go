@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/uptrace/bun"
+)
+
+// ErrSentNotRecorded is returned when a message is accepted by Telegram but can't be recorded, its outbox
+// message is given up instead of a retry which would post it again
+var ErrSentNotRecorded = errors.New("sent but not recorded")
+
+// enqueue put operation to the outbox once per idempotency key, the item of a pending operation is refreshed
+func enqueue(ctx context.Context, message *entity.OutboxMessage) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	message.ProviderID = provider.ID
+	message.FeedTitle = ctx.Value(config.CtxFeedTitleKey).(string)
+	_, err := dbConnect.NewInsert().Model(message).
+		On("CONFLICT (idempotency_key) DO UPDATE").
+		Set("item = EXCLUDED.item, feed_title = EXCLUDED.feed_title").
+		Where("o.sent_at IS NULL AND o.failed_at IS NULL").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s of record '%s': %v", message.Operation, message.EntryID, err)
+	}
+	return nil
+}
+
+// EnqueueSend put sending of a message of item to the channel to the outbox
+func EnqueueSend(ctx context.Context, item *config.FeedItem, channelID int) error {
+	return enqueue(ctx, &entity.OutboxMessage{
+		IdempotencyKey: fmt.Sprintf("send:%s:%d", item.GUID, channelID),
+		Operation:      entity.OutboxSend,
+		EntryID:        item.GUID,
+		ChannelID:      channelID,
+		Item:           item,
+	})
+}
+
+// EnqueueRepost put sending of a new message of item and delete of the message which can't be edited to the outbox
+func EnqueueRepost(ctx context.Context, item *config.FeedItem, message *entity.EntryMessage) error {
+	err := enqueue(ctx, &entity.OutboxMessage{
+		IdempotencyKey: fmt.Sprintf("repost:%s:%d:%d", item.GUID, message.ChannelID, message.MessageID),
+		Operation:      entity.OutboxSend,
+		EntryID:        item.GUID,
+		ChannelID:      message.ChannelID,
+		MessageID:      message.MessageID,
+		Item:           item,
+	})
+	if err != nil {
+		return err
+	}
+	return EnqueueDelete(ctx, item, message)
+}
+
+// EnqueueEdit put edit of the message of entry due at editAt to the outbox, version tells apart the edits of
+// the same message
+func EnqueueEdit(ctx context.Context, item *config.FeedItem, entry entity.Entry, message *entity.EntryMessage, version string, editAt time.Time) error {
+	return enqueue(ctx, &entity.OutboxMessage{
+		IdempotencyKey: fmt.Sprintf("edit:%s:%d:%d:%s", entry.ID, message.ChannelID, message.MessageID, version),
+		Operation:      entity.OutboxEdit,
+		EntryID:        entry.ID,
+		ChannelID:      message.ChannelID,
+		ChatID:         message.ChatID,
+		MessageID:      message.MessageID,
+		ImageURL:       entry.ImageURL,
+		Item:           item,
+		NextAttemptAt:  editAt,
+	})
+}
+
+// EnqueueRetract put edit of the message of entry marking the article withdrawn to the outbox
+func EnqueueRetract(ctx context.Context, entry entity.Entry, message *entity.EntryMessage) error {
+	return enqueue(ctx, &entity.OutboxMessage{
+		IdempotencyKey: fmt.Sprintf("retract:%s:%d:%d", entry.ID, message.ChannelID, message.MessageID),
+		Operation:      entity.OutboxRetract,
+		EntryID:        entry.ID,
+		ChannelID:      message.ChannelID,
+		ChatID:         message.ChatID,
+		MessageID:      message.MessageID,
+		Item:           &config.FeedItem{GUID: entry.ID, Link: entry.Link, Title: entry.Title},
+	})
+}
+
+// EnqueueDelete put delete of the message of item to the outbox
+func EnqueueDelete(ctx context.Context, item *config.FeedItem, message *entity.EntryMessage) error {
+	return enqueue(ctx, &entity.OutboxMessage{
+		IdempotencyKey: fmt.Sprintf("delete:%s:%d:%d", item.GUID, message.ChannelID, message.MessageID),
+		Operation:      entity.OutboxDelete,
+		EntryID:        item.GUID,
+		ChannelID:      message.ChannelID,
+		ChatID:         message.ChatID,
+		MessageID:      message.MessageID,
+		Item:           &config.FeedItem{GUID: item.GUID, Link: item.Link, Title: item.Title},
+	})
+}
+
+// ClaimDueOutboxMessages return pending outbox messages due to send and lease them to the caller, the attempt
+// is counted on claim, a message waits for the earlier pending messages of the same entry and channel
+func ClaimDueOutboxMessages(ctx context.Context, now time.Time) ([]*entity.OutboxMessage, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	due := dbConnect.NewSelect().Model((*entity.OutboxMessage)(nil)).Column("o.id").
+		Where("o.sent_at IS NULL AND o.failed_at IS NULL AND o.sending_at IS NULL AND o.next_attempt_at <= ?", now).
+		Where("NOT EXISTS (SELECT 1 FROM outbox AS prev WHERE prev.entry_id = o.entry_id AND prev.channel_id = o.channel_id AND prev.id < o.id AND prev.sent_at IS NULL AND prev.failed_at IS NULL)").
+		Order("o.id").Limit(config.OutboxBatchSize).For("UPDATE SKIP LOCKED")
+	var ids []int
+	err := dbConnect.NewUpdate().Model((*entity.OutboxMessage)(nil)).
+		Set("attempts = o.attempts + 1").
+		Set("next_attempt_at = ?", now.Add(config.OutboxLease)).
+		Where("o.id IN (?)", due).Returning("o.id").Scan(ctx, &ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim due outbox messages: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var messages []*entity.OutboxMessage
+	err = dbConnect.NewSelect().Model(&messages).Relation("Provider").Relation("Channel").
+		Where("o.id IN (?)", bun.In(ids)).Order("o.id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claimed outbox messages: %v", err)
+	}
+	return messages, nil
+}
+
+// MarkOutboxSending perform mark of outbox message in flight before it is sent, a message left in flight
+// is never sent again but checked by the caller of GetInterruptedOutboxMessages
+func MarkOutboxSending(ctx context.Context, message *entity.OutboxMessage) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	message.SendingAt = time.Now()
+	_, err := dbConnect.NewUpdate().Model(message).Column("sending_at").WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message %d sending: %v", message.ID, err)
+	}
+	return nil
+}
+
+// GetInterruptedOutboxMessages return outbox messages left in flight longer than the lease, e.g. by a crash
+// between the send and its record, whether they are sent is unknown
+func GetInterruptedOutboxMessages(ctx context.Context, now time.Time) ([]*entity.OutboxMessage, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var messages []*entity.OutboxMessage
+	err := dbConnect.NewSelect().Model(&messages).Relation("Provider").Relation("Channel").
+		Where("o.sent_at IS NULL AND o.failed_at IS NULL AND o.sending_at < ?", now.Add(-config.OutboxLease)).
+		Order("o.id").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interrupted outbox messages: %v", err)
+	}
+	return messages, nil
+}
+
+// MarkOutboxInterrupted perform give up of outbox message whose send has an unknown or unrecorded outcome,
+// it is left to the admin to check the chat
+func MarkOutboxInterrupted(ctx context.Context, message *entity.OutboxMessage, reason string, now time.Time) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	message.LastError = reason
+	message.FailedAt = now
+	_, err := dbConnect.NewUpdate().Model(message).Column("last_error", "failed_at").WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message %d interrupted: %v", message.ID, err)
+	}
+	return nil
+}
+
+// MarkOutboxSent perform mark of outbox message done
+func MarkOutboxSent(ctx context.Context, message *entity.OutboxMessage) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	message.SentAt = time.Now()
+	_, err := dbConnect.NewUpdate().Model(message).Column("sent_at").WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox message %d sent: %v", message.ID, err)
+	}
+	return nil
+}
+
+// MarkOutboxFailure perform record of failure of the claimed attempt of outbox message and schedule its retry,
+// true is returned when the message is given up after too many attempts
+func MarkOutboxFailure(ctx context.Context, message *entity.OutboxMessage, cause error, now time.Time) (bool, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	message.LastError = cause.Error()
+	backoff := config.OutboxRetryDelay << min(message.Attempts-1, 16)
+	if backoff > config.OutboxRetryDelayMax {
+		backoff = config.OutboxRetryDelayMax
+	}
+	message.NextAttemptAt = now.Add(backoff)
+	message.SendingAt = time.Time{}
+	failed := message.Attempts >= config.OutboxMaxAttempts
+	if failed {
+		message.FailedAt = now
+	}
+	_, err := dbConnect.NewUpdate().Model(message).Column("last_error", "next_attempt_at", "sending_at", "failed_at").WherePK().Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to record failure of outbox message %d: %v", message.ID, err)
+	}
+	return failed, nil
+}
+
+// DeliverOutboxSend perform send of outbox message by send and record of the sent message by record, only the
+// record is retried; once Telegram accepts the message the outbox message is never pending again, it is given up
+// with ErrSentNotRecorded if the message can't be recorded
+func DeliverOutboxSend(ctx context.Context, message *entity.OutboxMessage, send func() (*tgbotapi.Message, error), record func(*tgbotapi.Message) error) (*tgbotapi.Message, error) {
+	if err := MarkOutboxSending(ctx, message); err != nil {
+		return nil, err
+	}
+	sendedMsg, err := send()
+	if err != nil {
+		return nil, err
+	}
+	err = errors.New("empty MessageID")
+	for attempt := 0; sendedMsg.MessageID != 0 && attempt < config.OutboxRecordAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(config.TimeoutBetweenMessages)
+		}
+		if err = record(sendedMsg); err == nil {
+			return sendedMsg, nil
+		}
+	}
+	reason := fmt.Sprintf("message %d is sent but not recorded: %v", sendedMsg.MessageID, err)
+	if err := MarkOutboxInterrupted(ctx, message, reason, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %s, %v", ErrSentNotRecorded, reason, err)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrSentNotRecorded, reason)
+}
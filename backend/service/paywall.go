@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/misc"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultPaywallRules is the paywall rules of a provider without its own rules
+var DefaultPaywallRules = entity.PaywallRules{
+	AccessibleForFree: true,
+	Classes:           []string{"fragment--teaser"},
+	Patterns:          []string{`"isPremium"\s*:\s*true`},
+}
+
+// isAccessibleForFree return false if the JSON-LD article or one of its parts is marked not free
+func isAccessibleForFree(article map[string]any) bool {
+	switch value := article["isAccessibleForFree"].(type) {
+	case bool:
+		if !value {
+			return false
+		}
+	case string:
+		if strings.EqualFold(value, "false") {
+			return false
+		}
+	}
+	parts, _ := article["hasPart"].([]any)
+	if part, ok := article["hasPart"].(map[string]any); ok {
+		parts = []any{part}
+	}
+	for _, part := range parts {
+		if part, ok := part.(map[string]any); ok && !isAccessibleForFree(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectPaywall return whether the article page is paywalled by the rules
+func detectPaywall(doc *goquery.Document, body []byte, article map[string]any, rules *entity.PaywallRules) bool {
+	if rules == nil {
+		rules = &DefaultPaywallRules
+	}
+	if rules.AccessibleForFree && article != nil && !isAccessibleForFree(article) {
+		return true
+	}
+	for _, class := range rules.Classes {
+		if class != "" && doc.Find(fmt.Sprintf(`[class~="%s"]`, class)).Length() > 0 {
+			return true
+		}
+	}
+	for _, pattern := range rules.Patterns {
+		r, err := regexp.Compile(pattern)
+		if err != nil {
+			misc.Error("compile_paywall_pattern", fmt.Sprintf("compile paywall pattern '%s'", pattern), err)
+			continue
+		}
+		if r.Match(body) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPaywallSkipped return whether item is not published because the provider skips paywalled items
+func IsPaywallSkipped(ctx context.Context, item *config.FeedItem) bool {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	return item.Paywall && provider.PaywallPolicy == entity.PaywallSkip
+}
+
+// GetChatID return chat of a new message, paywalled items of a provider routing them go to the paywall chat
+// of the channel or to the one of the provider if the channel has no paywall chat
+func GetChatID(ctx context.Context, paywall bool) int64 {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	if paywall && provider.PaywallPolicy == entity.PaywallRoute {
+		if channel, ok := ctx.Value(config.CtxChannelKey).(*entity.Channel); ok && channel.PaywallChatID != 0 {
+			return channel.PaywallChatID
+		}
+		if provider.PaywallChatID != 0 {
+			return provider.PaywallChatID
+		}
+	}
+	return ctx.Value(config.CtxChatIDKey).(int64)
+}
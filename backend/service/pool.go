@@ -0,0 +1,84 @@
+package service
+
+import (
+	"sync"
+
+	"estonia-news/entity"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// FetchResult is a feed of a provider fetched by the pool or the error of the fetch
+type FetchResult struct {
+	Provider entity.Provider
+	Feed     *gofeed.Feed
+	Err      error
+}
+
+// FetchPool is a bounded pool of workers fetching feeds of providers, a provider is fetched by one worker
+// at a time and stays in flight until its result is processed
+type FetchPool struct {
+	tasks    chan entity.Provider
+	results  chan FetchResult
+	mu       sync.Mutex
+	inFlight map[int]bool
+}
+
+// FetchFeed return feed of provider by the source of its type
+func FetchFeed(provider *entity.Provider) (*gofeed.Feed, error) {
+	source, err := GetSource(provider)
+	if err != nil {
+		return nil, err
+	}
+	return source.Fetch(provider) //nolint:wrapcheck
+}
+
+// NewFetchPool return pool of workers fetching feeds by fetch
+func NewFetchPool(workers int, fetch func(provider *entity.Provider) (*gofeed.Feed, error)) *FetchPool {
+	pool := &FetchPool{
+		tasks:    make(chan entity.Provider),
+		results:  make(chan FetchResult),
+		inFlight: map[int]bool{},
+	}
+	for range workers {
+		go func() {
+			for provider := range pool.tasks {
+				feed, err := fetch(&provider)
+				pool.results <- FetchResult{Provider: provider, Feed: feed, Err: err}
+			}
+		}()
+	}
+	return pool
+}
+
+// IsFetching return true if provider is in flight
+func (p *FetchPool) IsFetching(providerID int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inFlight[providerID]
+}
+
+// Submit put provider to the pool, false is returned when provider is in flight already
+func (p *FetchPool) Submit(provider entity.Provider) bool {
+	p.mu.Lock()
+	if p.inFlight[provider.ID] {
+		p.mu.Unlock()
+		return false
+	}
+	p.inFlight[provider.ID] = true
+	p.mu.Unlock()
+	p.tasks <- provider
+	return true
+}
+
+// Results return channel of fetch results, Done has to be called for every processed result
+func (p *FetchPool) Results() <-chan FetchResult {
+	return p.results
+}
+
+// Done perform release of provider whose result is processed, so it can be fetched again
+func (p *FetchPool) Done(providerID int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.inFlight, providerID)
+}
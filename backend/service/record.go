@@ -24,8 +24,63 @@ func DeleteRecord(ctx context.Context, entry entity.Entry) error {
 	return nil
 }
 
-// UpsertRecord perform add/update record
-func UpsertRecord(ctx context.Context, item *config.FeedItem, messageID int) error {
+// MarkRecordEdited perform update of the time of the last edit of record, the time of a held edit is in the future
+func MarkRecordEdited(ctx context.Context, entryID string, editedAt time.Time) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(&entity.Entry{}).Set("edited_at = ?", editedAt).Where("id = ?", entryID).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to mark record '%s' edited: %v", entryID, err)
+	}
+	return nil
+}
+
+// GetRecord return record by id with its messages, nil is returned if the record is missing
+func GetRecord(ctx context.Context, entryID string) (*entity.Entry, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var entry entity.Entry
+	err := dbConnect.NewSelect().Model(&entry).Relation("Messages.Channel").Where("e.id = ?", entryID).Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get record '%s': %v", entryID, err)
+	}
+	return &entry, nil
+}
+
+// GetRecordMessage return message of record in channel, nil is returned if the record is not published in the channel
+func GetRecordMessage(entry *entity.Entry, channelID int) *entity.EntryMessage {
+	for _, message := range entry.Messages {
+		if message.ChannelID == channelID {
+			return message
+		}
+	}
+	return nil
+}
+
+// UpsertRecordMessage perform add/update message of record in a channel
+func UpsertRecordMessage(ctx context.Context, message *entity.EntryMessage) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewInsert().Model(message).ExcludeColumn("created_at").
+		On("CONFLICT (entry_id, channel_id) DO UPDATE").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add/update message of record '%s' in channel %d: %v", message.EntryID, message.ChannelID, err)
+	}
+	return nil
+}
+
+// DeleteRecordMessage perform delete message of record in a channel
+func DeleteRecordMessage(ctx context.Context, message *entity.EntryMessage) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewDelete().Model(message).WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete message of record '%s' in channel %d: %v", message.EntryID, message.ChannelID, err)
+	}
+	return nil
+}
+
+// UpsertRecord perform add/update record, imageFileID is Telegram file id of the uploaded image
+func UpsertRecord(ctx context.Context, item *config.FeedItem, imageFileID string) error {
 	pubDate, err := time.Parse(time.RFC1123Z, item.Published)
 	if err != nil {
 		misc.Fatal("parse_date", "parse date", err)
@@ -39,12 +94,22 @@ func UpsertRecord(ctx context.Context, item *config.FeedItem, messageID int) err
 		Title:       item.Title,
 		Description: item.Description,
 		ImageURL:    item.ImageURL,
+		ImageFileID: imageFileID,
 		Paywall:     item.Paywall,
 		PublishedAt: pubDate,
 		UpdatedAt:   time.Now(),
-		MessageID:   messageID,
 	}
-	_, err = dbConnect.NewInsert().Model(&entry).On("CONFLICT (id) DO UPDATE").Exec(ctx)
+	_, err = dbConnect.NewInsert().Model(&entry).ExcludeColumn("edited_at", "retracted_at").On("CONFLICT (id) DO UPDATE").Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add/update record '%s': %v", entry.ID, err)
+	}
+	err = entity.AddEntryRevision(ctx, &entity.EntryRevision{
+		EntryID:     entry.ID,
+		Link:        entry.Link,
+		Title:       entry.Title,
+		Description: entry.Description,
+		ImageURL:    entry.ImageURL,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to add/update record '%s': %v", entry.ID, err)
 	}
@@ -79,3 +144,15 @@ func UpsertRecord(ctx context.Context, item *config.FeedItem, messageID int) err
 	}
 	return nil
 }
+
+// SetRecordImageFileID perform update of the Telegram file id of the image of record, the id is ignored
+// if the image of record is changed meanwhile
+func SetRecordImageFileID(ctx context.Context, item *config.FeedItem, imageFileID string) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(&entity.Entry{}).Set("image_file_id = ?", imageFileID).
+		Where("id = ? AND image_url = ?", item.GUID, item.ImageURL).Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to set image file id of record '%s': %v", item.GUID, err)
+	}
+	return nil
+}
@@ -1,37 +1,108 @@
 package service
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"strings"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
 
-	"github.com/lafin/http"
-	"github.com/mmcdole/gofeed"
+	"estonia-news/entity"
 )
 
-// GetFeed return feed
-func GetFeed(feedURL string) (*gofeed.Feed, error) {
-	body, _, err := http.Get(feedURL, nil)
+// ErrNotModified is returned when the content has not changed since the last fetch
+var ErrNotModified = errors.New("not modified")
+
+// httpClient is the client shared by all requests, it is safe for concurrent use by the fetch workers
+var httpClient = newHTTPClient()
+
+func newHTTPClient() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{
+		Timeout: 300 * time.Second,
+		Jar:     jar,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     10 * time.Second,
+			TLSHandshakeTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// doRequest perform request by the shared client, the status code is returned as the error for statuses from 400
+func doRequest(req *http.Request, headers map[string]string) ([]byte, *http.Response, error) {
+	for k, v := range headers {
+		req.Header.Add(k, v)
+	}
+	res, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get feed from URL '%s': %v", feedURL, err)
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("%d", res.StatusCode)
 	}
-	fp := gofeed.NewParser()
-	feed, err := fp.ParseString(string(body))
+	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get feed from URL '%s': %v", feedURL, err)
+		return nil, nil, err
 	}
-	return feed, nil
+	return body, res, nil
 }
 
-func getImage(imageURL string) ([]byte, error) {
-	body, _, err := http.Get(imageURL, nil)
+// httpGet perform GET request
+func httpGet(link string, headers map[string]string) ([]byte, *http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, link, http.NoBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get image from URL '%s': %v", imageURL, err)
+		return nil, nil, err
+	}
+	return doRequest(req, headers)
+}
+
+// httpPost perform POST request
+func httpPost(link string, data io.Reader, headers map[string]string) ([]byte, *http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, link, data)
+	if err != nil {
+		return nil, nil, err
 	}
+	return doRequest(req, headers)
+}
+
+// FetchCached return content of provider URL by a conditional request,
+// the cache fields of the provider are updated with the response
+func FetchCached(provider *entity.Provider) ([]byte, error) {
+	headers := map[string]string{}
+	if provider.ETag != "" {
+		headers["If-None-Match"] = provider.ETag
+	}
+	if provider.LastModified != "" {
+		headers["If-Modified-Since"] = provider.LastModified
+	}
+	body, res, err := httpGet(provider.URL, headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch URL '%s': %v", provider.URL, err)
+	}
+	if res.StatusCode == 304 {
+		return nil, ErrNotModified
+	}
+	provider.ETag = res.Header.Get("ETag")
+	provider.LastModified = res.Header.Get("Last-Modified")
+	hash := sha256.Sum256(body)
+	contentHash := hex.EncodeToString(hash[:])
+	if contentHash == provider.ContentHash {
+		return nil, ErrNotModified
+	}
+	provider.ContentHash = contentHash
 	return body, nil
 }
 
-// IsLinkUnavailable return availability of link
-func IsLinkUnavailable(link string) bool {
-	body, res, _ := http.Get(link, nil)
-	return res != nil && (res.StatusCode == 404 || res.StatusCode == 200 && strings.Contains(string(body), "Artiklit ei leitud"))
+func getImage(imageURL string) ([]byte, error) {
+	body, _, err := httpGet(imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image from URL '%s': %v", imageURL, err)
+	}
+	return body, nil
 }
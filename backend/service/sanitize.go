@@ -0,0 +1,160 @@
+package service
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the tags kept by the sanitizer and the tags they are written as
+var allowedTags = map[string]string{
+	"b":          "b",
+	"strong":     "b",
+	"i":          "i",
+	"em":         "i",
+	"a":          "a",
+	"code":       "code",
+	"blockquote": "blockquote",
+}
+
+// skippedTags are the tags dropped together with their content
+var skippedTags = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"iframe":   true,
+	"template": true,
+	"svg":      true,
+	"head":     true,
+}
+
+// blockTags are the tags separated from the surrounding text by a new line
+var blockTags = map[string]bool{
+	"div":        true,
+	"li":         true,
+	"ul":         true,
+	"ol":         true,
+	"tr":         true,
+	"table":      true,
+	"h1":         true,
+	"h2":         true,
+	"h3":         true,
+	"h4":         true,
+	"h5":         true,
+	"h6":         true,
+	"section":    true,
+	"article":    true,
+	"header":     true,
+	"footer":     true,
+	"figure":     true,
+	"figcaption": true,
+	"blockquote": true,
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var (
+	lineSpacesRegexp = regexp.MustCompile(`[ \t]*\n[ \t]*`)
+	newLinesRegexp   = regexp.MustCompile(`\n{3,}`)
+)
+
+func getSafeLink(attributes []html.Attribute) string {
+	for _, attribute := range attributes {
+		if attribute.Key != "href" {
+			continue
+		}
+		link, err := url.Parse(strings.TrimSpace(attribute.Val))
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" {
+			return ""
+		}
+		return link.String()
+	}
+	return ""
+}
+
+// SanitizeHTML return text with the tags supported by Telegram only, other tags are removed with their markup,
+// script and style are removed with their content, paragraphs and line breaks become new lines
+func SanitizeHTML(text string) string {
+	tokenizer := html.NewTokenizer(strings.NewReader(text))
+	var result strings.Builder
+	var stack []string
+	skipDepth := 0
+	closeTag := func(name string) {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i] != name {
+				continue
+			}
+			for j := len(stack) - 1; j >= i; j-- {
+				result.WriteString("</" + stack[j] + ">")
+			}
+			stack = stack[:i]
+			return
+		}
+	}
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		if skipDepth > 0 {
+			switch {
+			case tokenType == html.StartTagToken && skippedTags[token.Data]:
+				skipDepth++
+			case tokenType == html.EndTagToken && skippedTags[token.Data]:
+				skipDepth--
+			}
+			continue
+		}
+		switch tokenType {
+		case html.TextToken:
+			result.WriteString(textEscaper.Replace(token.Data))
+		case html.StartTagToken, html.SelfClosingTagToken:
+			switch {
+			case skippedTags[token.Data]:
+				if tokenType == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			case token.Data == "br":
+				result.WriteString("\n")
+				continue
+			case token.Data == "p" || blockTags[token.Data]:
+				result.WriteString("\n")
+			}
+			name, ok := allowedTags[token.Data]
+			if !ok || tokenType == html.SelfClosingTagToken {
+				continue
+			}
+			if name == "a" {
+				link := getSafeLink(token.Attr)
+				if link == "" {
+					continue
+				}
+				result.WriteString(`<a href="` + html.EscapeString(link) + `">`)
+			} else {
+				result.WriteString("<" + name + ">")
+			}
+			stack = append(stack, name)
+		case html.EndTagToken:
+			if name, ok := allowedTags[token.Data]; ok {
+				closeTag(name)
+			}
+			switch {
+			case token.Data == "p":
+				result.WriteString("\n\n")
+			case blockTags[token.Data]:
+				result.WriteString("\n")
+			}
+		case html.CommentToken, html.DoctypeToken:
+		}
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		result.WriteString("</" + stack[i] + ">")
+	}
+	text = lineSpacesRegexp.ReplaceAllString(result.String(), "\n")
+	text = newLinesRegexp.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"estonia-news/entity"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/mmcdole/gofeed"
+)
+
+var spacesRegexp = regexp.MustCompile(`\s+`)
+
+func splitSelector(selector string) (path, attr string) {
+	if i := strings.LastIndex(selector, "@"); i >= 0 {
+		return selector[:i], selector[i+1:]
+	}
+	return selector, ""
+}
+
+func nodeValue(node *goquery.Selection, attr string) string {
+	if attr != "" {
+		value, _ := node.Attr(attr)
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(spacesRegexp.ReplaceAllString(node.Text(), " "))
+}
+
+// selectValue return the text or the attribute pointed by selector inside of item
+func selectValue(item *goquery.Selection, selector string) string {
+	if selector == "" {
+		return ""
+	}
+	path, attr := splitSelector(selector)
+	node := item
+	if path != "" {
+		node = item.Find(path).First()
+	}
+	return nodeValue(node, attr)
+}
+
+func resolveURL(base *url.URL, link string) string {
+	if link == "" {
+		return ""
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// ParseHTMLListing return feed parsed from a html listing by selectors,
+// items without a publish date are left undated to be dated by DateListingItems
+func ParseHTMLListing(body []byte, pageURL string, selectors *entity.Selectors) (*gofeed.Feed, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse listing '%s': %v", pageURL, err)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse listing '%s': %v", pageURL, err)
+	}
+	linkSelector := selectors.Link
+	if linkSelector != "" && !strings.Contains(linkSelector, "@") {
+		linkSelector += "@href"
+	}
+	layout := selectors.PublishedLayout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	feed := &gofeed.Feed{
+		FeedType: "html",
+		Title:    strings.TrimSpace(doc.Find("title").First().Text()),
+		Link:     pageURL,
+	}
+	doc.Find(selectors.Item).Each(func(_ int, node *goquery.Selection) {
+		link := resolveURL(base, selectValue(node, linkSelector))
+		title := selectValue(node, selectors.Title)
+		if link == "" || title == "" {
+			return
+		}
+		item := &gofeed.Item{
+			GUID:        link,
+			Link:        link,
+			Title:       title,
+			Description: selectValue(node, selectors.Description),
+		}
+		if image := resolveURL(base, selectValue(node, selectors.Image)); image != "" {
+			item.Image = &gofeed.Image{URL: image}
+		}
+		if selectors.Category != "" {
+			path, attr := splitSelector(selectors.Category)
+			node.Find(path).Each(func(_ int, category *goquery.Selection) {
+				if name := nodeValue(category, attr); name != "" {
+					item.Categories = append(item.Categories, name)
+				}
+			})
+		}
+		if value := selectValue(node, selectors.Published); value != "" {
+			if published, err := time.Parse(layout, value); err == nil {
+				item.PublishedParsed = &published
+				item.Published = published.Format(time.RFC1123Z)
+			}
+		}
+		feed.Items = append(feed.Items, item)
+	})
+	return feed, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+)
+
+type sitemapURLSet struct {
+	URLs []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+	News    struct {
+		Title           string `xml:"title"`
+		PublicationDate string `xml:"publication_date"`
+		Keywords        string `xml:"keywords"`
+		Publication     struct {
+			Name string `xml:"name"`
+		} `xml:"publication"`
+	} `xml:"news"`
+	Image struct {
+		Loc string `xml:"loc"`
+	} `xml:"image"`
+}
+
+func parseW3CDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return &date
+		}
+	}
+	return nil
+}
+
+// ParseSitemap return feed parsed from a news sitemap
+func ParseSitemap(body []byte) (*gofeed.Feed, error) {
+	var urlSet sitemapURLSet
+	if err := xml.Unmarshal(body, &urlSet); err != nil {
+		return nil, fmt.Errorf("failed to parse sitemap: %v", err)
+	}
+	feed := &gofeed.Feed{FeedType: "sitemap"}
+	for _, entry := range urlSet.URLs {
+		if entry.Loc == "" || entry.News.Title == "" {
+			continue
+		}
+		if feed.Title == "" {
+			feed.Title = entry.News.Publication.Name
+		}
+		item := &gofeed.Item{
+			GUID:  strings.TrimSpace(entry.Loc),
+			Link:  strings.TrimSpace(entry.Loc),
+			Title: strings.TrimSpace(entry.News.Title),
+		}
+		for _, keyword := range strings.Split(entry.News.Keywords, ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				item.Categories = append(item.Categories, keyword)
+			}
+		}
+		if entry.Image.Loc != "" {
+			item.Image = &gofeed.Image{URL: strings.TrimSpace(entry.Image.Loc)}
+		}
+		date := parseW3CDate(entry.News.PublicationDate)
+		if date == nil {
+			date = parseW3CDate(entry.LastMod)
+		}
+		if date != nil {
+			item.PublishedParsed = date
+			item.Published = date.Format(time.RFC1123Z)
+		}
+		feed.Items = append(feed.Items, item)
+	}
+	return feed, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/uptrace/bun"
+)
+
+// Source is a source of feed items
+type Source interface {
+	Fetch(provider *entity.Provider) (*gofeed.Feed, error)
+}
+
+// GetSource return source by provider type
+func GetSource(provider *entity.Provider) (Source, error) {
+	switch provider.Type {
+	case "", entity.SourceRSS:
+		return &feedSource{}, nil
+	case entity.SourceJSON:
+		return &feedSource{feedType: "json"}, nil
+	case entity.SourceSitemap:
+		return &sitemapSource{}, nil
+	case entity.SourceHTML:
+		return &htmlSource{}, nil
+	}
+	return nil, fmt.Errorf("unknown source type '%s' for provider '%d'", provider.Type, provider.ID)
+}
+
+type feedSource struct {
+	feedType string
+}
+
+func (s *feedSource) Fetch(provider *entity.Provider) (*gofeed.Feed, error) {
+	body, err := FetchCached(provider)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := gofeed.NewParser().ParseString(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed from URL '%s': %v", provider.URL, err)
+	}
+	if s.feedType != "" && feed.FeedType != s.feedType {
+		return nil, fmt.Errorf("failed to get feed from URL '%s': unexpected feed type '%s'", provider.URL, feed.FeedType)
+	}
+	normalizeFeed(feed)
+	return feed, nil
+}
+
+type sitemapSource struct{}
+
+func (s *sitemapSource) Fetch(provider *entity.Provider) (*gofeed.Feed, error) {
+	body, err := FetchCached(provider)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := ParseSitemap(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sitemap from URL '%s': %v", provider.URL, err)
+	}
+	if feed.Title == "" {
+		feed.Title = provider.Name
+	}
+	return feed, nil
+}
+
+type htmlSource struct{}
+
+func (s *htmlSource) Fetch(provider *entity.Provider) (*gofeed.Feed, error) {
+	if provider.Selectors == nil {
+		return nil, fmt.Errorf("failed to get listing from URL '%s': empty selectors", provider.URL)
+	}
+	body, err := FetchCached(provider)
+	if err != nil {
+		return nil, err
+	}
+	feed, err := ParseHTMLListing(body, provider.URL, provider.Selectors)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listing from URL '%s': %v", provider.URL, err)
+	}
+	if feed.Title == "" {
+		feed.Title = provider.Name
+	}
+	return feed, nil
+}
+
+// normalizeFeed bring publish dates to the format stored for entries
+func normalizeFeed(feed *gofeed.Feed) {
+	for _, item := range feed.Items {
+		if item.PublishedParsed != nil {
+			item.Published = item.PublishedParsed.Format(time.RFC1123Z)
+		}
+	}
+}
+
+// SaveFeedCache perform save of conditional request fields of provider,
+// it is called after the feed is processed, so a failed feed is fetched again
+func SaveFeedCache(ctx context.Context, provider *entity.Provider) error {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewUpdate().Model(provider).Column("etag", "last_modified", "content_hash").WherePK().Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save feed cache for provider '%d': %v", provider.ID, err)
+	}
+	return nil
+}
+
+// DateListingItems perform dating of undated items of a feed by the time they are first seen,
+// so an item staying on the listing keeps its date and gets old like a dated one
+func DateListingItems(ctx context.Context, provider *entity.Provider, feed *gofeed.Feed, now time.Time) error {
+	undated := make([]*entity.ListingItem, 0, len(feed.Items))
+	links := make([]string, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		if item.PublishedParsed != nil || item.Link == "" {
+			continue
+		}
+		undated = append(undated, &entity.ListingItem{ProviderID: provider.ID, Link: item.Link, FirstSeenAt: now, LastSeenAt: now})
+		links = append(links, item.Link)
+	}
+	if len(undated) == 0 {
+		return nil
+	}
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	_, err := dbConnect.NewInsert().Model(&undated).
+		On("CONFLICT (provider_id, link) DO UPDATE").
+		Set("last_seen_at = EXCLUDED.last_seen_at").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save listing items of provider '%d': %v", provider.ID, err)
+	}
+	var seen []*entity.ListingItem
+	err = dbConnect.NewSelect().Model(&seen).
+		Where("li.provider_id = ? AND li.link IN (?)", provider.ID, bun.In(links)).
+		Scan(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get listing items of provider '%d': %v", provider.ID, err)
+	}
+	firstSeen := make(map[string]time.Time, len(seen))
+	for _, item := range seen {
+		firstSeen[item.Link] = item.FirstSeenAt
+	}
+	for _, item := range feed.Items {
+		if item.PublishedParsed != nil {
+			continue
+		}
+		if published, ok := firstSeen[item.Link]; ok {
+			item.PublishedParsed = &published
+			item.Published = published.Format(time.RFC1123Z)
+		}
+	}
+	return nil
+}
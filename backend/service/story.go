@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+
+	"github.com/uptrace/bun"
+)
+
+// FindSimilarEntry return the most similar published entry of another provider of the same language,
+// so it is published in the same channels, an item published already is never matched; an entry counts as
+// published once its send is queued in the outbox
+func FindSimilarEntry(ctx context.Context, item *config.FeedItem) (*entity.Entry, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	var entry entity.Entry
+	err := dbConnect.NewSelect().Model(&entry).Relation("Provider").
+		Where("e.updated_at > ?", time.Now().Add(-config.SimilarityWindow)).
+		Where("e.provider_id != ?", provider.ID).
+		Where("provider.lang = ?", provider.Lang).
+		Where("e.retracted_at IS NULL").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("EXISTS (SELECT 1 FROM entry_messages AS em WHERE em.entry_id = e.id)").
+				WhereOr("EXISTS (SELECT 1 FROM outbox AS ob WHERE ob.entry_id = e.id AND ob.operation = ? AND ob.sent_at IS NULL AND ob.failed_at IS NULL)", entity.OutboxSend)
+		}).
+		Where("similarity(?, e.title) > ?", item.Title, config.SimilarityThreshold).
+		Where("NOT EXISTS (SELECT 1 FROM entries WHERE id = ?)", item.GUID).
+		OrderExpr("similarity(?, e.title) DESC", item.Title).
+		Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find similar entry for '%s': %v", item.GUID, err)
+	}
+	return &entry, nil
+}
+
+// AddStorySource attach item to the story of entry, the story is created if missing,
+// false is returned when the item is already attached
+func AddStorySource(ctx context.Context, entry *entity.Entry, item *config.FeedItem) (bool, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	var story entity.Story
+	err := dbConnect.NewSelect().Model(&story).Where("entry_id = ?", entry.ID).Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		story = entity.Story{EntryID: entry.ID, CreatedAt: time.Now()}
+		_, err = dbConnect.NewInsert().Model(&story).Exec(ctx)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to add '%s' to story of '%s': %v", item.GUID, entry.ID, err)
+	}
+	res, err := dbConnect.NewInsert().Model(&entity.StorySource{
+		StoryID:    story.ID,
+		EntryID:    item.GUID,
+		ProviderID: provider.ID,
+		Link:       item.Link,
+		Title:      item.Title,
+		CreatedAt:  time.Now(),
+	}).On("CONFLICT (story_id, entry_id) DO NOTHING").Exec(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to add '%s' to story of '%s': %v", item.GUID, entry.ID, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to add '%s' to story of '%s': %v", item.GUID, entry.ID, err)
+	}
+	return affected > 0, nil
+}
+
+// IsStorySource return true if item is attached to a story, e.g. a version of a held story which lost to
+// the version of another provider
+func IsStorySource(ctx context.Context, item *config.FeedItem) (bool, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	exists, err := dbConnect.NewSelect().Model((*entity.StorySource)(nil)).Where("entry_id = ?", item.GUID).Exists(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check story source '%s': %v", item.GUID, err)
+	}
+	return exists, nil
+}
+
+// GetStorySources return items of other providers attached to the story of entry
+func GetStorySources(ctx context.Context, entryID string) ([]*entity.StorySource, error) {
+	dbConnect := ctx.Value(config.CtxDBKey).(*bun.DB)
+	var sources []*entity.StorySource
+	err := dbConnect.NewSelect().Model(&sources).Relation("Provider").
+		Join("JOIN stories AS s ON s.id = ss.story_id").
+		Where("s.entry_id = ?", entryID).
+		Order("ss.created_at").Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get story sources of '%s': %v", entryID, err)
+	}
+	return sources, nil
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmlpkg "html"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/misc"
+
+	"github.com/thoas/go-funk"
+	"golang.org/x/net/html"
+)
+
+// DefaultTextTemplate is the template of a message text used if no template is set
+const DefaultTextTemplate = "<b>{{.Title}}</b>\n\n{{.Description}}{{if .Hashtags}}\n\n{{join .Hashtags \" \"}}{{end}}"
+
+// DefaultButtonTemplate is the template of a message button used if no template is set
+const DefaultButtonTemplate = "{{if .Paywall}}💰{{end}}{{readOn .Lang}} {{.Provider}}"
+
+// TemplateData is the data available in message templates, title and description are sanitized HTML,
+// the other text fields are escaped in the text template and left as is in the button template
+type TemplateData struct {
+	Title       string
+	Description string
+	Categories  []string
+	Topics      []string
+	Hashtags    []string
+	Provider    string
+	FeedTitle   string
+	Lang        string
+	Published   time.Time
+	Paywall     bool
+	Link        string
+}
+
+var telegramTags = map[string][]string{
+	"b":          nil,
+	"strong":     nil,
+	"i":          nil,
+	"em":         nil,
+	"u":          nil,
+	"ins":        nil,
+	"s":          nil,
+	"strike":     nil,
+	"del":        nil,
+	"span":       {"class"},
+	"tg-spoiler": nil,
+	"a":          {"href"},
+	"code":       {"class"},
+	"pre":        nil,
+	"blockquote": {"expandable"},
+	"tg-emoji":   {"emoji-id"},
+}
+
+func getReadOnText(lang string) string {
+	switch lang {
+	case "EST":
+		return "Loe edasi"
+	case "RUS":
+		return "Читать на"
+	}
+	return "Read on"
+}
+
+var templateFuncs = template.FuncMap{
+	"escape": htmlpkg.EscapeString,
+	"join":   strings.Join,
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"readOn": getReadOnText,
+}
+
+func escapeStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return funk.Map(values, htmlpkg.EscapeString).([]string)
+}
+
+// escapeTemplateData return copy of data with the plain text fields escaped for a template rendering HTML
+func escapeTemplateData(data *TemplateData) *TemplateData {
+	escaped := *data
+	escaped.Categories = escapeStrings(data.Categories)
+	escaped.Topics = escapeStrings(data.Topics)
+	escaped.Hashtags = escapeStrings(data.Hashtags)
+	escaped.Provider = htmlpkg.EscapeString(data.Provider)
+	escaped.FeedTitle = htmlpkg.EscapeString(data.FeedTitle)
+	escaped.Link = htmlpkg.EscapeString(data.Link)
+	return &escaped
+}
+
+func parseTemplate(name, body string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template '%s': %v", name, err)
+	}
+	return tmpl, nil
+}
+
+func executeTemplate(tmpl *template.Template, data *TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template '%s': %v", tmpl.Name(), err)
+	}
+	return buf.String(), nil
+}
+
+// ValidateTelegramHTML check that text uses only tags and attributes supported by Telegram and the tags are balanced
+func ValidateTelegramHTML(text string) error {
+	tokenizer := html.NewTokenizer(strings.NewReader(text))
+	var stack []string
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if !errors.Is(tokenizer.Err(), io.EOF) {
+				return fmt.Errorf("failed to parse HTML: %v", tokenizer.Err())
+			}
+			if len(stack) > 0 {
+				return fmt.Errorf("unclosed tag <%s>", stack[len(stack)-1])
+			}
+			return nil
+		case html.StartTagToken:
+			token := tokenizer.Token()
+			attributes, ok := telegramTags[token.Data]
+			if !ok {
+				return fmt.Errorf("unsupported tag <%s>", token.Data)
+			}
+			for _, attribute := range token.Attr {
+				if !funk.ContainsString(attributes, attribute.Key) {
+					return fmt.Errorf("unsupported attribute '%s' of tag <%s>", attribute.Key, token.Data)
+				}
+			}
+			stack = append(stack, token.Data)
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			if len(stack) == 0 || stack[len(stack)-1] != token.Data {
+				return fmt.Errorf("unexpected closing tag </%s>", token.Data)
+			}
+			stack = stack[:len(stack)-1]
+		case html.SelfClosingTagToken, html.CommentToken, html.DoctypeToken:
+			return fmt.Errorf("unsupported markup '%s'", tokenizer.Raw())
+		case html.TextToken:
+		}
+	}
+}
+
+// ValidateTemplate check that template is executable and renders to Telegram HTML
+func ValidateTemplate(name, body string) error {
+	if name != entity.TemplateText && name != entity.TemplateButton {
+		return fmt.Errorf("unknown template '%s'", name)
+	}
+	tmpl, err := parseTemplate(name, body)
+	if err != nil {
+		return err
+	}
+	data := &TemplateData{
+		Title:       "Title",
+		Description: "Description",
+		Categories:  []string{"Culture & <Art>"},
+		Topics:      []string{"Topic"},
+		Hashtags:    []string{"#topic"},
+		Provider:    "Provider & Co",
+		FeedTitle:   "Feed <News>",
+		Lang:        "ENG",
+		Published:   time.Now(),
+		Paywall:     true,
+		Link:        "https://example.com/news?id=1&utm_source=rss",
+	}
+	if name == entity.TemplateText {
+		data = escapeTemplateData(data)
+	}
+	text, err := executeTemplate(tmpl, data)
+	if err != nil {
+		return err
+	}
+	if name == entity.TemplateButton {
+		if strings.TrimSpace(text) == "" {
+			return errors.New("empty button text")
+		}
+		return nil
+	}
+	return ValidateTelegramHTML(text)
+}
+
+// RenderTemplate return data rendered by the template of the provider and channel from ctx,
+// the default template is used if there is no template or it failed
+func RenderTemplate(ctx context.Context, name string, data *TemplateData) string {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	channelID := 0
+	if channel, ok := ctx.Value(config.CtxChannelKey).(*entity.Channel); ok {
+		channelID = channel.ID
+	}
+	body := DefaultTextTemplate
+	if name == entity.TemplateButton {
+		body = DefaultButtonTemplate
+	} else {
+		data = escapeTemplateData(data)
+	}
+	custom, err := entity.GetTemplate(ctx, provider.ID, channelID, name)
+	if err != nil {
+		misc.Error("render_template", fmt.Sprintf("get template '%s'", name), err)
+	} else if custom != nil {
+		tmpl, err := parseTemplate(name, custom.Body)
+		if err == nil {
+			var text string
+			text, err = executeTemplate(tmpl, data)
+			if err == nil {
+				return text
+			}
+		}
+		misc.Error("render_template", fmt.Sprintf("render template %d", custom.ID), err)
+	}
+	tmpl := template.Must(parseTemplate(name, body))
+	text, err := executeTemplate(tmpl, data)
+	if err != nil {
+		misc.Error("render_template", fmt.Sprintf("render default template '%s'", name), err)
+	}
+	return text
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/misc"
+)
+
+// Trace record the decision about item at a stage of the pipeline, the decision is per channel in the context
+// of a channel, true is returned for the first decision about item at the stage, a failure is only logged
+func Trace(ctx context.Context, item *config.FeedItem, stage, decision, reason string) bool {
+	provider := ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	channelID := 0
+	if channel, ok := ctx.Value(config.CtxChannelKey).(*entity.Channel); ok {
+		channelID = channel.ID
+	}
+	first, err := entity.SaveDecision(ctx, &entity.ItemDecision{
+		EntryID:    item.GUID,
+		Stage:      stage,
+		ProviderID: provider.ID,
+		ChannelID:  channelID,
+		Link:       item.Link,
+		Title:      item.Title,
+		Decision:   decision,
+		Reason:     reason,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		misc.Error("trace_item", fmt.Sprintf("trace item '%s'", item.GUID), err)
+	}
+	return first
+}
+
+// DescribeFilterRule return short description of a filter rule
+func DescribeFilterRule(rule *entity.FilterRule) string {
+	if rule.ID == 0 {
+		return fmt.Sprintf("blocked category '%s'", rule.Pattern)
+	}
+	return fmt.Sprintf("rule %d (%s %s '%s')", rule.ID, rule.Field, rule.Match, rule.Pattern)
+}
@@ -1,25 +1,212 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
+	"strings"
+	"time"
 
-	"github.com/lafin/http"
+	"estonia-news/entity"
+	"estonia-news/misc"
+
+	"github.com/uptrace/bun"
 )
 
-func translate(query, from, to string) (string, error) {
-	body, _, err := http.Get(fmt.Sprintf("https://translate.googleapis.com/translate_a/single?client=gtx&sl=%s&tl=%s&dt=t&q=%s", from, to, url.QueryEscape(query)), nil)
+// Translator is a translation backend, languages are ISO 639-1 codes
+type Translator interface {
+	Translate(ctx context.Context, text, from, to string) (string, error)
+}
+
+var languageCodes = map[string]string{
+	"EST": "et",
+	"RUS": "ru",
+	"ENG": "en",
+	"LAV": "lv",
+	"LIT": "lt",
+	"FIN": "fi",
+	"UKR": "uk",
+	"DEU": "de",
+	"FRA": "fr",
+}
+
+// LanguageCode return ISO 639-1 code of a provider language
+func LanguageCode(lang string) string {
+	if code, ok := languageCodes[strings.ToUpper(lang)]; ok {
+		return code
+	}
+	return strings.ToLower(lang)
+}
+
+// NewTranslator return translator by name of the backend
+func NewTranslator(name, baseURL, key string) (Translator, error) {
+	switch name {
+	case "", "google":
+		return NewGoogleTranslator(baseURL), nil
+	case "libre":
+		return NewLibreTranslator(baseURL, key), nil
+	case "deepl":
+		return NewDeepLTranslator(baseURL, key), nil
+	}
+	return nil, fmt.Errorf("unknown translator '%s'", name)
+}
+
+type googleTranslator struct {
+	baseURL string
+}
+
+// NewGoogleTranslator return translator by the public Google Translate endpoint
+func NewGoogleTranslator(baseURL string) Translator {
+	if baseURL == "" {
+		baseURL = "https://translate.googleapis.com"
+	}
+	return &googleTranslator{baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (t *googleTranslator) Translate(_ context.Context, text, from, to string) (string, error) {
+	body, _, err := httpGet(fmt.Sprintf("%s/translate_a/single?client=gtx&sl=%s&tl=%s&dt=t&q=%s", t.baseURL, from, to, url.QueryEscape(text)), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, query, err)
+		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, text, err)
 	}
 	var data []any
 	if err := json.Unmarshal(body, &data); err != nil {
-		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, query, err)
+		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, text, err)
+	}
+	if len(data) == 0 {
+		return "", errors.New("empty translation")
+	}
+	segments, _ := data[0].([]any)
+	var translation strings.Builder
+	for _, segment := range segments {
+		parts, _ := segment.([]any)
+		if len(parts) == 0 {
+			continue
+		}
+		if part, ok := parts[0].(string); ok {
+			translation.WriteString(part)
+		}
+	}
+	if translation.Len() == 0 {
+		return "", errors.New("empty translation")
+	}
+	return translation.String(), nil
+}
+
+type libreTranslator struct {
+	baseURL string
+	apiKey  string
+}
+
+// NewLibreTranslator return translator by a LibreTranslate compatible API
+func NewLibreTranslator(baseURL, apiKey string) Translator {
+	return &libreTranslator{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey}
+}
+
+func (t *libreTranslator) Translate(_ context.Context, text, from, to string) (string, error) {
+	request, err := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  from,
+		"target":  to,
+		"format":  "text",
+		"api_key": t.apiKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, text, err)
+	}
+	body, _, err := httpPost(t.baseURL+"/translate", bytes.NewReader(request), map[string]string{"Content-Type": "application/json"})
+	if err != nil {
+		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, text, err)
+	}
+	var data struct {
+		TranslatedText string `json:"translatedText"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, text, err)
 	}
-	if data == nil {
+	if data.TranslatedText == "" {
 		return "", errors.New("empty translation")
 	}
-	return data[0].([]any)[0].([]any)[0].(string), nil
+	return data.TranslatedText, nil
+}
+
+type deeplTranslator struct {
+	baseURL string
+	authKey string
+}
+
+// NewDeepLTranslator return translator by a DeepL compatible API
+func NewDeepLTranslator(baseURL, authKey string) Translator {
+	if baseURL == "" {
+		baseURL = "https://api-free.deepl.com"
+	}
+	return &deeplTranslator{baseURL: strings.TrimRight(baseURL, "/"), authKey: authKey}
+}
+
+func (t *deeplTranslator) Translate(_ context.Context, text, from, to string) (string, error) {
+	target := strings.ToUpper(to)
+	if target == "EN" {
+		target = "EN-GB"
+	}
+	form := url.Values{"text": {text}, "source_lang": {strings.ToUpper(from)}, "target_lang": {target}}
+	body, _, err := httpPost(t.baseURL+"/v2/translate", strings.NewReader(form.Encode()), map[string]string{
+		"Content-Type":  "application/x-www-form-urlencoded",
+		"Authorization": "DeepL-Auth-Key " + t.authKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, text, err)
+	}
+	var data struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", fmt.Errorf("failed to get translation from '%s' to '%s' for query '%s': %v", from, to, text, err)
+	}
+	if len(data.Translations) == 0 || data.Translations[0].Text == "" {
+		return "", errors.New("empty translation")
+	}
+	return data.Translations[0].Text, nil
+}
+
+type cachedTranslator struct {
+	translator Translator
+	dbConnect  *bun.DB
+}
+
+// NewCachedTranslator return translator storing translations in the database by hash of the source text
+func NewCachedTranslator(translator Translator, dbConnect *bun.DB) Translator {
+	return &cachedTranslator{translator: translator, dbConnect: dbConnect}
+}
+
+func (t *cachedTranslator) Translate(ctx context.Context, text, from, to string) (string, error) {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%s", from, to, text)))
+	translation := entity.Translation{
+		Hash:       hex.EncodeToString(hash[:]),
+		SourceLang: from,
+		TargetLang: to,
+	}
+	err := t.dbConnect.NewSelect().Model(&translation).WherePK().Scan(ctx)
+	if err == nil {
+		return translation.Text, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		misc.Error("get_cached_translation", "get cached translation", err)
+	}
+	translation.Text, err = t.translator.Translate(ctx, text, from, to)
+	if err != nil {
+		return "", err //nolint:wrapcheck
+	}
+	translation.CreatedAt = time.Now()
+	_, err = t.dbConnect.NewInsert().Model(&translation).On("CONFLICT (hash) DO NOTHING").Exec(ctx)
+	if err != nil {
+		misc.Error("cache_translation", "cache translation", err)
+	}
+	return translation.Text, nil
 }
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"golang.org/x/net/html"
+)
+
+const ellipsis = "…"
+
+type htmlToken struct {
+	tokenType html.TokenType
+	name      string
+	raw       string
+	text      []rune
+}
+
+func tokenizeHTML(text string) []htmlToken {
+	tokenizer := html.NewTokenizer(strings.NewReader(text))
+	var tokens []htmlToken
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return tokens
+		}
+		raw := string(tokenizer.Raw())
+		token := tokenizer.Token()
+		tokens = append(tokens, htmlToken{tokenType: tokenType, name: token.Data, raw: raw, text: []rune(token.Data)})
+	}
+}
+
+func utf16Len(runes []rune) int {
+	length := 0
+	for _, r := range runes {
+		length += utf16.RuneLen(r)
+	}
+	return length
+}
+
+// VisibleLength return length of text without markup in UTF-16 code units as counted by Telegram
+func VisibleLength(text string) int {
+	length := 0
+	for _, token := range tokenizeHTML(text) {
+		if token.tokenType == html.TextToken {
+			length += utf16Len(token.text)
+		}
+	}
+	return length
+}
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || r == '…'
+}
+
+// findCut return the count of visible runes to keep, preferring the end of a sentence and then the end of a word
+func findCut(visible []rune, limit int) (int, bool) {
+	n := 0
+	for length := 0; n < len(visible); n++ {
+		length += utf16.RuneLen(visible[n])
+		if length > limit {
+			break
+		}
+	}
+	for i := n; i > n/2; i-- {
+		if visible[i-1] == '\n' || (isSentenceEnd(visible[i-1]) && (i == len(visible) || unicode.IsSpace(visible[i]))) {
+			return i, true
+		}
+	}
+	for i := n; i > 0; i-- {
+		if i < len(visible) && unicode.IsSpace(visible[i]) {
+			return i, false
+		}
+	}
+	return n, false
+}
+
+// TruncateHTML return text shortened to limit of visible length at a sentence boundary when possible,
+// open tags are closed and an ellipsis is added
+func TruncateHTML(text string, limit int) string {
+	if VisibleLength(text) <= limit {
+		return text
+	}
+	tokens := tokenizeHTML(text)
+	var visible []rune
+	for _, token := range tokens {
+		if token.tokenType == html.TextToken {
+			visible = append(visible, token.text...)
+		}
+	}
+	suffix := ellipsis
+	cut, sentence := findCut(visible, max(limit-utf16Len([]rune(" "+ellipsis)), 0))
+	if sentence {
+		suffix = " " + ellipsis
+	}
+	kept := strings.TrimRightFunc(string(visible[:cut]), unicode.IsSpace)
+	remaining := len([]rune(kept))
+	var result strings.Builder
+	var stack []string
+	for _, token := range tokens {
+		if remaining == 0 {
+			break
+		}
+		switch token.tokenType {
+		case html.TextToken:
+			part := token.text
+			if len(part) > remaining {
+				part = part[:remaining]
+			}
+			remaining -= len(part)
+			result.WriteString(textEscaper.Replace(string(part)))
+		case html.StartTagToken:
+			stack = append(stack, token.name)
+			result.WriteString(token.raw)
+		case html.EndTagToken:
+			if len(stack) > 0 && stack[len(stack)-1] == token.name {
+				stack = stack[:len(stack)-1]
+			}
+			result.WriteString(token.raw)
+		case html.SelfClosingTagToken, html.CommentToken, html.DoctypeToken, html.ErrorToken:
+			result.WriteString(token.raw)
+		}
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		result.WriteString("</" + stack[i] + ">")
+	}
+	return result.String() + suffix
+}
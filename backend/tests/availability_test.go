@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readFixture(t *SuiteTest, name string) []byte {
+	body, err := os.ReadFile("fixtures/" + name)
+	if err != nil {
+		t.T().Fatal(err)
+	}
+	return body
+}
+
+func (t *SuiteTest) Test_Availability_DetectUnavailable() {
+	rules := &entity.Availability{
+		StatusCodes:       []int{404, 410},
+		Markers:           []string{"Artiklit ei leitud", "Статья не найдена"},
+		RedirectToRoot:    true,
+		CanonicalMismatch: true,
+	}
+	article := readFixture(t, "err_article.html")
+	link := "https://www.err.ee/1609000001/riigikogu-vottis-vastu-uue-eelarve"
+	assert.Empty(t.T(), service.DetectUnavailable(rules, link, 200, link, article))
+	assert.Equal(t.T(), "status code 410", service.DetectUnavailable(rules, link, 410, link, nil))
+	assert.Empty(t.T(), service.DetectUnavailable(rules, link, 503, link, nil))
+	assert.Equal(t.T(), "page contains 'Artiklit ei leitud'",
+		service.DetectUnavailable(rules, link, 200, link, readFixture(t, "err_not_found.html")))
+
+	link = "https://rus.delfi.ee/statja/120000003/udalennaja-statja"
+	assert.Equal(t.T(), "page contains 'Статья не найдена'",
+		service.DetectUnavailable(rules, link, 200, link, readFixture(t, "delfi_rus_not_found.html")))
+
+	link = "https://www.delfi.ee/artikkel/120000003/eemaldatud-artikkel"
+	home := readFixture(t, "delfi_home.html")
+	assert.Equal(t.T(), "redirected to 'https://www.delfi.ee/'",
+		service.DetectUnavailable(rules, link, 200, "https://www.delfi.ee/", home))
+	assert.Empty(t.T(), service.DetectUnavailable(&entity.Availability{}, link, 200, "https://www.delfi.ee/", home))
+
+	link = "https://www.postimees.ee/8000000/tallinnas-avati-uus-sild?utm_source=rss"
+	assert.Empty(t.T(), service.DetectUnavailable(rules, link, 200, link, readFixture(t, "postimees_article.html")))
+	link = "https://www.postimees.ee/8000002/eemaldatud-artikkel"
+	assert.Equal(t.T(), "canonical link is 'https://www.postimees.ee/section/1'",
+		service.DetectUnavailable(rules, link, 200, link, readFixture(t, "postimees_moved.html")))
+
+	link = "https://www.postimees.ee/8000000/vana-pealkiri"
+	renamed := readFixture(t, "postimees_article.html")
+	assert.Empty(t.T(), service.DetectUnavailable(rules, link, 200, "https://www.postimees.ee/8000000/tallinnas-avati-uus-sild", renamed))
+	assert.Empty(t.T(), service.DetectUnavailable(rules, link, 200, link, renamed))
+
+	assert.Equal(t.T(), "status code 404", service.DetectUnavailable(nil, link, 404, link, nil))
+	assert.Empty(t.T(), service.DetectUnavailable(nil, link, 410, link, nil))
+}
+
+func (t *SuiteTest) Test_Availability_IsLinkUnavailable() {
+	home := readFixture(t, "delfi_home.html")
+	notFound := readFixture(t, "err_not_found.html")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			_, _ = w.Write(home)
+		case "/removed":
+			http.Redirect(w, r, "/", http.StatusFound)
+		case "/missing":
+			_, _ = w.Write(notFound)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	provider := &entity.Provider{Availability: &entity.Availability{
+		StatusCodes:    []int{404, 410},
+		Markers:        []string{"Artiklit ei leitud"},
+		RedirectToRoot: true,
+	}}
+	unavailable, reason := service.IsLinkUnavailable(provider, server.URL+"/removed")
+	assert.True(t.T(), unavailable)
+	assert.Equal(t.T(), "redirected to '"+server.URL+"/'", reason)
+	unavailable, _ = service.IsLinkUnavailable(provider, server.URL+"/missing")
+	assert.True(t.T(), unavailable)
+	unavailable, reason = service.IsLinkUnavailable(provider, server.URL+"/gone")
+	assert.True(t.T(), unavailable)
+	assert.Equal(t.T(), "status code 410", reason)
+	unavailable, _ = service.IsLinkUnavailable(provider, server.URL+"/error")
+	assert.False(t.T(), unavailable)
+	unavailable, _ = service.IsLinkUnavailable(&entity.Provider{}, server.URL+"/removed")
+	assert.False(t.T(), unavailable)
+}
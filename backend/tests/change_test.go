@@ -0,0 +1,35 @@
+package tests
+
+import (
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Change_GetChanges() {
+	entry := entity.Entry{
+		Title:       "Riigikogu kiitis eelnõu heaks",
+		Description: "<p>Eelnõu  toetas 60 saadikut.</p>",
+		Link:        "https://news.err.ee/123",
+		ImageURL:    "https://s.err.ee/photo/1.jpg",
+	}
+	item := &config.FeedItem{
+		Title:       " Riigikogu kiitis eelnõu heaks\n",
+		Description: "Eelnõu toetas 60&nbsp;saadikut.",
+		Link:        "https://news.err.ee/123?utm_source=rss#top",
+		ImageURL:    "https://s.err.ee/photo/1.jpg",
+	}
+	assert.Empty(t.T(), service.GetChanges(item, entry))
+
+	item.Title = "Riigikogu lükkas eelnõu tagasi"
+	item.ImageURL = "https://s.err.ee/photo/2.jpg"
+	item.Paywall = true
+	assert.Equal(t.T(), []string{service.ChangeFieldTitle, service.ChangeFieldImage, service.ChangeFieldPaywall}, service.GetChanges(item, entry))
+
+	significant := config.SignificantFields
+	defer func() { config.SignificantFields = significant }()
+	config.SignificantFields = map[string]bool{service.ChangeFieldTitle: true}
+	assert.Equal(t.T(), []string{service.ChangeFieldTitle}, service.GetSignificantChanges(item, entry))
+}
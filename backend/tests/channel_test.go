@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Channel_AddChannel_GetChannelsByLang() {
+	LoadFixtures(t)
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}))
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, &entity.Channel{Name: "rus", ChatID: -200, SourceLangs: []string{"ru", "et"}, TranslateLang: "ru"}))
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, &entity.Channel{Name: "est-news", ChatID: -100, SourceLangs: []string{"et"}}))
+	assert.NoError(t.T(), entity.EnsureChannel(t.ctx, &entity.Channel{Name: "ignored", ChatID: -200, SourceLangs: []string{"en"}}))
+
+	channels, err := entity.GetChannels(t.ctx)
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 2, len(channels)) {
+		assert.Equal(t.T(), "est-news", channels[0].Name)
+		assert.Equal(t.T(), []string{"ru", "et"}, channels[1].SourceLangs)
+	}
+	byLang, err := entity.GetChannelsByLang(t.ctx, "et")
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 2, len(byLang))
+	}
+	byLang, err = entity.GetChannelsByLang(t.ctx, "ru")
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 1, len(byLang)) {
+		assert.Equal(t.T(), "ru", byLang[0].TranslateLang)
+		assert.NoError(t.T(), entity.DeleteChannel(t.ctx, byLang[0].ID))
+	}
+	byLang, err = entity.GetChannelsByLang(t.ctx, "ru")
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), byLang)
+	}
+}
+
+func (t *SuiteTest) Test_Channel_GetChannelTargets() {
+	LoadFixtures(t)
+	provider := t.ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	provider.Lang = "et"
+	first := &entity.Channel{Name: "first", ChatID: -100, SourceLangs: []string{"et"}}
+	second := &entity.Channel{Name: "second", ChatID: -200, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, first))
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, second))
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, &entity.Channel{Name: "other", ChatID: -300, SourceLangs: []string{"ru"}}))
+	assert.NoError(t.T(), entity.AddFilterRule(t.ctx, &entity.FilterRule{ProviderID: provider.ID, ChannelID: second.ID, Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Pattern: "sport"}))
+	assert.NoError(t.T(), entity.AddFilterRule(t.ctx, &entity.FilterRule{Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Pattern: "weather"}))
+
+	ctx := context.WithValue(t.ctx, config.CtxProviderKey, provider)
+	targets, err := service.GetChannelTargets(ctx)
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 2, len(targets)) {
+		item := &config.FeedItem{Title: "sport news"}
+		assert.Nil(t.T(), targets[0].Filter.Match(item))
+		if rule := targets[1].Filter.Match(item); assert.NotNil(t.T(), rule) {
+			assert.Equal(t.T(), "sport", rule.Pattern)
+		}
+	}
+	rules, err := entity.GetFilterRules(ctx, provider.ID, 0)
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 1, len(rules)) {
+		assert.Equal(t.T(), "weather", rules[0].Pattern)
+	}
+}
+
+func (t *SuiteTest) Test_Channel_UpsertRecordMessage() {
+	LoadFixtures(t)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	message := &entity.EntryMessage{EntryID: "err#123-1000000000000", ChannelID: channel.ID, ChatID: channel.ChatID, MessageID: 10, MessageType: entity.MessageTypeText}
+	assert.NoError(t.T(), service.UpsertRecordMessage(t.ctx, message))
+	message.MessageID = 11
+	assert.NoError(t.T(), service.UpsertRecordMessage(t.ctx, message))
+
+	entry, err := service.GetRecord(t.ctx, "err#123-1000000000000")
+	if assert.NoError(t.T(), err) && assert.NotNil(t.T(), entry) {
+		if stored := service.GetRecordMessage(entry, channel.ID); assert.NotNil(t.T(), stored) {
+			assert.Equal(t.T(), 11, stored.MessageID)
+			assert.Equal(t.T(), "est", stored.Channel.Name)
+		}
+		assert.Nil(t.T(), service.GetRecordMessage(entry, channel.ID+1))
+	}
+	assert.NoError(t.T(), service.DeleteRecordMessage(t.ctx, message))
+	entry, err = service.GetRecord(t.ctx, "err#123-1000000000000")
+	if assert.NoError(t.T(), err) && assert.NotNil(t.T(), entry) {
+		assert.Empty(t.T(), entry.Messages)
+	}
+	entry, err = service.GetRecord(t.ctx, "err#999")
+	assert.NoError(t.T(), err)
+	assert.Nil(t.T(), entry)
+}
@@ -1,6 +1,10 @@
 package tests
 
 import (
+	"errors"
+	"time"
+
+	"estonia-news/config"
 	"estonia-news/entity"
 
 	"github.com/stretchr/testify/assert"
@@ -60,3 +64,110 @@ func (t *SuiteTest) Test_Command_AddCategoryToBlock_DeleteCategoryFromBlock() {
 		assert.Equal(t.T(), categories[1].ID, res[0].CategoryID)
 	}
 }
+
+func (t *SuiteTest) Test_Command_GetProviders_ScheduleProvider() {
+	LoadFixtures(t)
+	res, err := entity.GetProviders(t.ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 2, len(res))
+		assert.Equal(t.T(), 300, res[0].PollInterval)
+		assert.True(t.T(), res[0].NextRunAt.IsZero())
+	}
+	now := time.Now()
+	err = entity.ScheduleProvider(t.ctx, &res[0], now)
+	assert.NoError(t.T(), err)
+	res, err = entity.GetProviders(t.ctx)
+	if assert.NoError(t.T(), err) {
+		assert.WithinDuration(t.T(), now, res[0].LastRunAt, time.Second)
+		assert.WithinDuration(t.T(), now.Add(5*time.Minute), res[0].NextRunAt, time.Second)
+		assert.True(t.T(), res[1].LastRunAt.IsZero())
+	}
+}
+
+func (t *SuiteTest) Test_Command_RecordProviderFailure_RecordProviderSuccess() {
+	LoadFixtures(t)
+	res, _ := entity.GetProviders(t.ctx)
+	provider := res[0]
+	now := time.Now()
+	for i := 1; i < config.CircuitBreakerThreshold; i++ {
+		opened, err := entity.RecordProviderFailure(t.ctx, &provider, errors.New("timeout"), now)
+		assert.NoError(t.T(), err)
+		assert.False(t.T(), opened)
+	}
+	assert.True(t.T(), provider.NextRunAt.Before(now.Add(config.RetryBackoffMax+time.Second)))
+	opened, err := entity.RecordProviderFailure(t.ctx, &provider, errors.New("timeout"), now)
+	assert.NoError(t.T(), err)
+	assert.True(t.T(), opened)
+	opened, err = entity.RecordProviderFailure(t.ctx, &provider, errors.New("timeout"), now)
+	assert.NoError(t.T(), err)
+	assert.False(t.T(), opened)
+	res, err = entity.GetProviders(t.ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), config.CircuitBreakerThreshold+1, res[0].FailureCount)
+		assert.Equal(t.T(), "timeout", res[0].LastError)
+		assert.WithinDuration(t.T(), now.Add(config.CircuitBreakerTimeout), res[0].CircuitOpenUntil, time.Second)
+		assert.Equal(t.T(), 0, res[1].FailureCount)
+	}
+	err = entity.RecordProviderSuccess(t.ctx, &provider)
+	assert.NoError(t.T(), err)
+	res, err = entity.GetProviders(t.ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 0, res[0].FailureCount)
+		assert.True(t.T(), res[0].CircuitOpenUntil.IsZero())
+	}
+}
+
+func (t *SuiteTest) Test_Command_SaveDecision_GetDecisions() {
+	LoadFixtures(t)
+	var providers []entity.Provider
+	_ = t.db.NewSelect().Model(&providers).Scan(t.ctx)
+	decision := entity.ItemDecision{EntryID: "err#555-1000000000000", Stage: entity.StageFilter, ProviderID: providers[0].ID, Link: "https://news.err.ee/555", Decision: entity.DecisionPassed, UpdatedAt: time.Now()}
+	inserted, err := entity.SaveDecision(t.ctx, &decision)
+	assert.NoError(t.T(), err)
+	assert.True(t.T(), inserted)
+	decision.Decision = entity.DecisionRejected
+	decision.Reason = "blocked"
+	inserted, err = entity.SaveDecision(t.ctx, &decision)
+	assert.NoError(t.T(), err)
+	assert.False(t.T(), inserted)
+	_, err = entity.SaveDecision(t.ctx, &entity.ItemDecision{EntryID: "err#555-1000000000000", Stage: entity.StageTerm, ProviderID: providers[0].ID, Link: "https://news.err.ee/555", Decision: entity.DecisionPassed, UpdatedAt: time.Now()})
+	assert.NoError(t.T(), err)
+	res, err := entity.GetDecisions(t.ctx, "err#555")
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 2, len(res))
+		assert.Equal(t.T(), entity.DecisionRejected, res[0].Decision)
+		assert.Equal(t.T(), "blocked", res[0].Reason)
+	}
+	res, err = entity.GetDecisions(t.ctx, "https://news.err.ee/555")
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 2, len(res))
+	}
+
+	channels := []*entity.Channel{{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}, {Name: "rus", ChatID: -200, SourceLangs: []string{"et"}}}
+	for _, channel := range channels {
+		assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	}
+	published := entity.ItemDecision{EntryID: "err#555-1000000000000", Stage: entity.StagePublish, ProviderID: providers[0].ID, ChannelID: channels[0].ID, Decision: entity.DecisionPublished, UpdatedAt: time.Now()}
+	_, err = entity.SaveDecision(t.ctx, &published)
+	assert.NoError(t.T(), err)
+	blocked := entity.ItemDecision{EntryID: "err#555-1000000000000", Stage: entity.StageFilter, ProviderID: providers[0].ID, ChannelID: channels[1].ID, Decision: entity.DecisionRejected, Reason: "blocked in channel rus", UpdatedAt: time.Now()}
+	_, err = entity.SaveDecision(t.ctx, &blocked)
+	assert.NoError(t.T(), err)
+	blocked.Reason = "blocked again"
+	_, err = entity.SaveDecision(t.ctx, &blocked)
+	assert.NoError(t.T(), err)
+	res, err = entity.GetDecisions(t.ctx, "err#555")
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 4, len(res)) {
+		reasons := map[string]string{}
+		for _, decision := range res {
+			name := ""
+			if decision.Channel != nil {
+				name = decision.Channel.Name
+			}
+			reasons[decision.Stage+"@"+name] = decision.Decision + ": " + decision.Reason
+		}
+		assert.Equal(t.T(), "rejected: blocked", reasons[entity.StageFilter+"@"])
+		assert.Equal(t.T(), "rejected: blocked again", reasons[entity.StageFilter+"@rus"])
+		assert.Equal(t.T(), "published: ", reasons[entity.StagePublish+"@est"])
+	}
+}
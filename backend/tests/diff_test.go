@@ -0,0 +1,14 @@
+package tests
+
+import (
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Diff_DiffWords() {
+	assert.Equal(t.T(), "Riigikogu [-kiitis-] {+lükkas+} eelnõu {+tagasi+}", service.DiffWords("Riigikogu kiitis eelnõu", "Riigikogu lükkas eelnõu tagasi"))
+	assert.Equal(t.T(), "same text", service.DiffWords("same  text", "same text"))
+	assert.Equal(t.T(), "{+new+}", service.DiffWords("", "new"))
+	assert.Equal(t.T(), "[-old-]", service.DiffWords("old", ""))
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Filter_Match() {
+	item := &config.FeedItem{
+		Title:       "Kallas: Eesti toetab Ukrainat",
+		Description: "Peaminister kohtus Kiievis",
+		Link:        "https://news.err.ee/123?utm_source=ads.example.com",
+		Categories:  []string{"Sport", "Eesti"},
+		Author:      "Mari Maasikas",
+	}
+	rules := []*entity.FilterRule{
+		{ID: 1, Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Match: entity.FilterMatchWord, Pattern: "eesti"},
+	}
+	rule := service.NewFilter(rules).Match(item)
+	if assert.NotNil(t.T(), rule) {
+		assert.Equal(t.T(), 1, rule.ID)
+	}
+
+	rules[0].CaseSensitive = true
+	assert.Nil(t.T(), service.NewFilter(rules).Match(item))
+
+	rules = []*entity.FilterRule{
+		{ID: 1, Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Match: entity.FilterMatchWord, Pattern: "Ees"},
+		{ID: 2, Action: entity.FilterActionBlock, Field: entity.FilterFieldAuthor, Match: entity.FilterMatchRegex, Pattern: `^mari\s`},
+	}
+	rule = service.NewFilter(rules).Match(item)
+	if assert.NotNil(t.T(), rule) {
+		assert.Equal(t.T(), 2, rule.ID)
+	}
+
+	rules = []*entity.FilterRule{
+		{ID: 1, Action: entity.FilterActionBlock, Field: entity.FilterFieldCategory, Match: entity.FilterMatchExact, Pattern: "Sport"},
+		{ID: 2, Action: entity.FilterActionAllow, Field: entity.FilterFieldText, Match: entity.FilterMatchSubstring, Pattern: "ukrain"},
+	}
+	rule = service.NewFilter(rules).Match(item)
+	if assert.NotNil(t.T(), rule) {
+		assert.Equal(t.T(), entity.FilterActionAllow, rule.Action)
+	}
+
+	rules[0].Priority = 10
+	rule = service.NewFilter(rules).Match(item)
+	if assert.NotNil(t.T(), rule) {
+		assert.Equal(t.T(), entity.FilterActionBlock, rule.Action)
+	}
+
+	rules = []*entity.FilterRule{
+		{ID: 1, Action: entity.FilterActionBlock, Field: entity.FilterFieldLink, Pattern: "ads.example.com"},
+		{ID: 2, Action: entity.FilterActionBlock, Field: entity.FilterFieldAny, Match: entity.FilterMatchRegex, Pattern: "("},
+	}
+	rule = service.NewFilter(rules).Match(item)
+	if assert.NotNil(t.T(), rule) {
+		assert.Equal(t.T(), 1, rule.ID)
+	}
+}
+
+func (t *SuiteTest) Test_Filter_GetFilterRules_CountFilterRuleHit() {
+	LoadFixtures(t)
+	var providers []entity.Provider
+	_ = t.db.NewSelect().Model(&providers).Scan(t.ctx)
+	assert.NoError(t.T(), entity.AddFilterRule(t.ctx, &entity.FilterRule{Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Pattern: "global"}))
+	assert.NoError(t.T(), entity.AddFilterRule(t.ctx, &entity.FilterRule{ProviderID: providers[0].ID, Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Pattern: "first", Priority: 1}))
+	assert.NoError(t.T(), entity.AddFilterRule(t.ctx, &entity.FilterRule{ProviderID: providers[1].ID, Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Pattern: "second"}))
+	rules, err := entity.GetFilterRules(t.ctx, providers[0].ID, 0)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 2, len(rules))
+		assert.Equal(t.T(), "first", rules[0].Pattern)
+		assert.Equal(t.T(), "global", rules[1].Pattern)
+	}
+	assert.NoError(t.T(), entity.CountFilterRuleHit(t.ctx, rules[0].ID))
+	assert.NoError(t.T(), entity.CountFilterRuleHit(t.ctx, rules[0].ID))
+	res, err := entity.GetListFilterRules(t.ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 3, len(res))
+		assert.EqualValues(t.T(), 2, res[0].Hits)
+	}
+}
+
+func (t *SuiteTest) Test_Filter_Decide() {
+	LoadFixtures(t)
+	var providers []entity.Provider
+	_ = t.db.NewSelect().Model(&providers).Order("id").Scan(t.ctx)
+	assert.NoError(t.T(), entity.AddFilterRule(t.ctx, &entity.FilterRule{ProviderID: providers[0].ID, Action: entity.FilterActionAllow, Field: entity.FilterFieldTitle, Pattern: "allowed", Priority: 1}))
+	assert.NoError(t.T(), entity.AddFilterRule(t.ctx, &entity.FilterRule{ProviderID: providers[0].ID, Action: entity.FilterActionBlock, Field: entity.FilterFieldTitle, Pattern: "news"}))
+	rules, err := entity.GetFilterRules(t.ctx, providers[0].ID, 0)
+	if !assert.NoError(t.T(), err) || !assert.Equal(t.T(), 2, len(rules)) {
+		return
+	}
+	filter := service.NewFilter(rules)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	channelCtx := context.WithValue(t.ctx, config.CtxChannelKey, channel)
+
+	blocked := &config.FeedItem{GUID: "err#1-1000000000000", Title: "news"}
+	allowed := &config.FeedItem{GUID: "err#2-1000000000000", Title: "allowed news"}
+	for range 2 {
+		assert.False(t.T(), filter.Decide(t.ctx, blocked, ""))
+		assert.True(t.T(), filter.Decide(t.ctx, allowed, ""))
+	}
+	hits := func() map[string]int64 {
+		res, err := entity.GetListFilterRules(t.ctx)
+		assert.NoError(t.T(), err)
+		values := map[string]int64{}
+		for _, rule := range res {
+			values[rule.Pattern] = rule.Hits
+		}
+		return values
+	}
+	assert.Equal(t.T(), map[string]int64{"allowed": 1, "news": 1}, hits())
+
+	for range 2 {
+		assert.False(t.T(), filter.Decide(channelCtx, blocked, " in channel est"))
+		assert.True(t.T(), filter.Decide(channelCtx, allowed, " in channel est"))
+	}
+	assert.Equal(t.T(), map[string]int64{"allowed": 2, "news": 2}, hits())
+	res, err := entity.GetDecisions(t.ctx, "err#1-1000000000000")
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 2, len(res)) {
+		assert.Equal(t.T(), "blocked in channel est by "+service.DescribeFilterRule(rules[1]), res[1].Reason)
+	}
+}
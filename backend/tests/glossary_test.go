@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Glossary_ProtectTerms() {
+	terms := []*entity.GlossaryTerm{
+		{SourceLang: "et", Term: "Isamaa"},
+		{SourceLang: "et", Term: "Eesti 200"},
+		{SourceLang: "et", TargetLang: "en", Term: "Riigikogu", Translation: "Riigikogu (parliament)"},
+		{SourceLang: "et", Term: "Eesti"},
+	}
+	text, values := service.ProtectTerms("Isamaa ja Eesti 200 lahkusid Riigikogust, Isamaa kritiseeris Riigikogu", terms)
+	assert.Equal(t.T(), "⟦2⟧ ja ⟦0⟧ lahkusid Riigikogust, ⟦3⟧ kritiseeris ⟦1⟧", text)
+	assert.Equal(t.T(), []string{"Eesti 200", "Riigikogu (parliament)", "Isamaa", "Isamaa"}, values)
+
+	translation := "⟦2⟧ and ⟦ 0 ⟧ left the Riigikogu, ⟦3⟧ criticised ⟦1⟧ and ⟦9⟧"
+	assert.Equal(t.T(), "Isamaa and Eesti 200 left the Riigikogu, Isamaa criticised Riigikogu (parliament) and ⟦9⟧", service.RestoreTerms(translation, values))
+
+	terms = []*entity.GlossaryTerm{{SourceLang: "et", Term: "ab"}}
+	text, values = service.ProtectTerms("abab ab, xab ab", terms)
+	assert.Equal(t.T(), "abab ⟦0⟧, xab ⟦1⟧", text)
+	assert.Equal(t.T(), []string{"ab", "ab"}, values)
+	text, values = service.ProtectTerms("aab ab", terms)
+	assert.Equal(t.T(), "aab ⟦0⟧", text)
+	assert.Equal(t.T(), []string{"ab"}, values)
+}
+
+func (t *SuiteTest) Test_Glossary_GetGlossaryTerms() {
+	assert.NoError(t.T(), entity.AddGlossaryTerm(t.ctx, &entity.GlossaryTerm{SourceLang: "et", TargetLang: "en", Term: "Keskerakond", Translation: "Centre Party"}))
+	assert.NoError(t.T(), entity.AddGlossaryTerm(t.ctx, &entity.GlossaryTerm{SourceLang: "et", TargetLang: "en", Term: "Keskerakond", Translation: "Center Party"}))
+	assert.NoError(t.T(), entity.AddGlossaryTerm(t.ctx, &entity.GlossaryTerm{SourceLang: "et", TargetLang: "ru", Term: "Keskerakond", Translation: "Центристская партия"}))
+	assert.Error(t.T(), entity.AddGlossaryTerm(t.ctx, &entity.GlossaryTerm{SourceLang: "et"}))
+
+	terms, err := entity.GetGlossaryTerms(t.ctx, "et", "en")
+	if assert.NoError(t.T(), err) {
+		translations := map[string]string{}
+		for _, term := range terms {
+			translations[term.Term] = term.Translation
+		}
+		assert.Equal(t.T(), "Center Party", translations["Keskerakond"])
+		assert.Contains(t.T(), translations, "Riigikogu")
+	}
+	terms, err = entity.GetGlossaryTerms(t.ctx, "ru", "en")
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), terms)
+	}
+}
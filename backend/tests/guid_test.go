@@ -0,0 +1,44 @@
+package tests
+
+import (
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_GUID_ExtractGUID() {
+	delfi := &entity.Provider{Name: "Delfi", GUIDRules: []*entity.GUIDRule{
+		{Field: entity.GUIDFieldGUID, Pattern: `delfi.*?/(\d+)/.*?$`, Prefix: "delfi"},
+		{Field: entity.GUIDFieldQuery, Param: "id", Pattern: `^(\d+)$`, Prefix: "delfi"},
+	}}
+	guid, err := service.ExtractGUID(delfi, &gofeed.Item{GUID: "https://www.delfi.ee/artikkel/120123456/pealkiri"})
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "delfi#120123456", guid)
+	}
+	guid, err = service.ExtractGUID(delfi, &gofeed.Item{Link: "https://www.delfi.ee/news?id=98765"})
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "delfi#98765", guid)
+	}
+	guid, err = service.ExtractGUID(delfi, &gofeed.Item{GUID: "delfi#555"})
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "delfi#555", guid)
+	}
+
+	postimees := &entity.Provider{Name: "Postimees"}
+	guid1, err := service.ExtractGUID(postimees, &gofeed.Item{Link: "https://news.postimees.ee/7412345/title?utm_source=rss#comments"})
+	assert.NoError(t.T(), err)
+	guid2, err := service.ExtractGUID(postimees, &gofeed.Item{Link: "https://NEWS.postimees.ee/7412345/title/"})
+	assert.NoError(t.T(), err)
+	assert.Regexp(t.T(), `^postimees#[0-9a-f]{16}$`, guid1)
+	assert.Equal(t.T(), guid1, guid2)
+
+	_, err = service.ExtractGUID(postimees, &gofeed.Item{})
+	assert.Error(t.T(), err)
+}
+
+func (t *SuiteTest) Test_GUID_CanonicalURL() {
+	assert.Equal(t.T(), "https://news.err.ee/123/title?a=1&b=2", service.CanonicalURL("HTTPS://News.ERR.ee/123/title/?b=2&utm_medium=rss&a=1&fbclid=x#top"))
+	assert.Equal(t.T(), "not a url", service.CanonicalURL(" not a url "))
+}
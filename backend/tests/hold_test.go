@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"context"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Hold_ChooseBestItem() {
+	errProvider := &entity.Provider{Name: "ERR", Priority: 1}
+	delfiProvider := &entity.Provider{Name: "Delfi"}
+	items := []*entity.PendingItem{
+		{EntryID: "delfi#1", Provider: delfiProvider, Item: &config.FeedItem{ImageURL: "image", Description: "long description"}},
+		{EntryID: "err#1", Provider: errProvider, Item: &config.FeedItem{Paywall: true}},
+	}
+	assert.Equal(t.T(), "err#1", service.ChooseBestItem(items).EntryID)
+	errProvider.Priority = 0
+	assert.Equal(t.T(), "delfi#1", service.ChooseBestItem(items).EntryID)
+	items[1].Item.Paywall = false
+	items[1].Item.ImageURL = "image"
+	items[1].Item.Description = "longer description"
+	assert.Equal(t.T(), "err#1", service.ChooseBestItem(items).EntryID)
+	items[1].Item.ImageURL = ""
+	assert.Equal(t.T(), "delfi#1", service.ChooseBestItem(items).EntryID)
+	assert.Nil(t.T(), service.ChooseBestItem(nil))
+}
+
+func (t *SuiteTest) Test_Hold_HoldItem_GetReleasedItems() {
+	LoadFixtures(t)
+	config.HoldWindow = time.Minute
+	defer func() { config.HoldWindow = 0 }()
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "feed")
+	held, err := service.HoldItem(ctx, &config.FeedItem{GUID: "err#123-1000000000000"})
+	if assert.NoError(t.T(), err) {
+		assert.False(t.T(), held)
+	}
+	held, err = service.HoldItem(ctx, &config.FeedItem{GUID: "err#777-1000000000000", Title: "title"})
+	if assert.NoError(t.T(), err) {
+		assert.True(t.T(), held)
+	}
+	held, err = service.HoldItem(ctx, &config.FeedItem{GUID: "err#777-1000000000000", Title: "new title"})
+	if assert.NoError(t.T(), err) {
+		assert.True(t.T(), held)
+	}
+	items, err := service.GetReleasedItems(ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 0, len(items))
+	}
+	_, err = t.db.NewUpdate().Model(&entity.PendingItem{}).Set("release_at = ?", time.Now().Add(-time.Second)).Where("entry_id = ?", "err#777-1000000000000").Exec(ctx)
+	assert.NoError(t.T(), err)
+	items, err = service.GetReleasedItems(ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 1, len(items))
+		assert.Equal(t.T(), "new title", items[0].Item.Title)
+		assert.Equal(t.T(), "feed", items[0].FeedTitle)
+	}
+	assert.NoError(t.T(), service.DeleteHeldItems(ctx, items))
+	items, err = service.GetReleasedItems(ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 0, len(items))
+	}
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+
+	"estonia-news/config"
+	"estonia-news/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeImage(t *SuiteTest, format string, width, height int, fill color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, fill)
+		}
+	}
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	default:
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.T().Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func (t *SuiteTest) Test_Image_PrepareImage() {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	for _, body := range [][]byte{
+		nil,
+		[]byte("<html><body>Not found</body></html>"),
+		encodeImage(t, "png", 50, 50, red),
+		encodeImage(t, "png", 4200, 200, red),
+	} {
+		_, err := service.PrepareImage(body)
+		assert.ErrorIs(t.T(), err, service.ErrUnusableImage)
+	}
+
+	for _, format := range []string{"jpeg", "png"} {
+		body := encodeImage(t, format, 300, 200, red)
+		prepared, err := service.PrepareImage(body)
+		if assert.NoError(t.T(), err) {
+			assert.Equal(t.T(), body, prepared)
+		}
+	}
+
+	prepared, err := service.PrepareImage(encodeImage(t, "gif", 300, 200, red))
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "image/jpeg", http.DetectContentType(prepared))
+	}
+
+	prepared, err = service.PrepareImage(encodeImage(t, "png", 3000, 1500, red))
+	if assert.NoError(t.T(), err) {
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(prepared))
+		if assert.NoError(t.T(), err) {
+			assert.Equal(t.T(), "jpeg", format)
+			assert.Equal(t.T(), config.ImageMaxDimension, cfg.Width)
+			assert.Equal(t.T(), config.ImageMaxDimension/2, cfg.Height)
+		}
+	}
+
+	maxSize := config.ImageMaxSize
+	config.ImageMaxSize = 2000
+	defer func() { config.ImageMaxSize = maxSize }()
+	prepared, err = service.PrepareImage(encodeImage(t, "png", 600, 400, color.RGBA{}))
+	if assert.NoError(t.T(), err) {
+		assert.LessOrEqual(t.T(), len(prepared), config.ImageMaxSize)
+		img, err := jpeg.Decode(bytes.NewReader(prepared))
+		if assert.NoError(t.T(), err) {
+			r, g, b, _ := img.At(0, 0).RGBA()
+			assert.Greater(t.T(), min(r, g, b), uint32(0xf000))
+		}
+	}
+}
+
+func (t *SuiteTest) Test_Image_GetPhotoFileID() {
+	assert.Empty(t.T(), service.GetPhotoFileID(&tgbotapi.Message{}))
+	assert.Equal(t.T(), "big", service.GetPhotoFileID(&tgbotapi.Message{Photo: []tgbotapi.PhotoSize{
+		{FileID: "small", Width: 90},
+		{FileID: "big", Width: 1280},
+	}}))
+}
+
+func (t *SuiteTest) Test_Image_CheckImage() {
+	LoadFixtures(t)
+	requests := 0
+	photo := encodeImage(t, "jpeg", 300, 200, color.RGBA{R: 0xff, A: 0xff})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/photo.jpg":
+			_, _ = w.Write(photo)
+		case "/icon.png":
+			_, _ = w.Write(encodeImage(t, "png", 16, 16, color.RGBA{A: 0xff}))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	item := &config.FeedItem{ImageURL: server.URL + "/photo.jpg"}
+	assert.NoError(t.T(), service.CheckImage(t.ctx, item))
+	assert.Equal(t.T(), server.URL+"/photo.jpg", item.ImageURL)
+
+	for range 2 {
+		item = &config.FeedItem{ImageURL: server.URL + "/icon.png"}
+		assert.ErrorIs(t.T(), service.CheckImage(t.ctx, item), service.ErrUnusableImage)
+		assert.Empty(t.T(), item.ImageURL)
+	}
+	item = &config.FeedItem{ImageURL: server.URL + "/missing.jpg"}
+	assert.NoError(t.T(), service.CheckImage(t.ctx, item))
+	assert.Equal(t.T(), server.URL+"/missing.jpg", item.ImageURL)
+	assert.Equal(t.T(), 3, requests)
+
+	item = &config.FeedItem{ImageURL: server.URL + "/photo.jpg"}
+	assert.NoError(t.T(), service.CheckImage(t.ctx, item))
+	assert.Equal(t.T(), 3, requests)
+
+	item = &config.FeedItem{
+		GUID:      "pm#123-1000000000000",
+		Published: "Mon, 02 Jan 2006 15:04:05 -0700",
+		Title:     "title",
+		ImageURL:  server.URL + "/photo.jpg",
+	}
+	assert.NoError(t.T(), service.UpsertRecord(t.ctx, item, "file-id"))
+	assert.NoError(t.T(), service.CheckImage(t.ctx, item))
+	assert.Equal(t.T(), 3, requests)
+}
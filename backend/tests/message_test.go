@@ -1,8 +1,19 @@
 package tests
 
 import (
+	"context"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
 	"estonia-news/service"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -79,3 +90,52 @@ func (t *SuiteTest) Test_Message_FormatText() {
 	message4 := `Ööl vastu 24.<img src="http://feeds.feedburner.com/~r/delfiuudised/~4/t4DO-Uy3On4" height="1" width="1" alt=""/>`
 	assert.Equal(t.T(), "Ööl vastu 24.", service.CleanUpText(message4))
 }
+
+func (t *SuiteTest) Test_Message_Edit() {
+	LoadFixtures(t)
+	image := encodeImage(t, "png", 300, 200, color.RGBA{R: 0xff, A: 0xff})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(image)
+	}))
+	defer server.Close()
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	ctx = context.WithValue(ctx, config.CtxTranslateLangKey, "")
+	item := &config.FeedItem{GUID: "err#123-1000000000000", Link: "https://err.ee/123", Title: "Title", Description: "Description"}
+	entry := entity.Entry{ID: item.GUID}
+	message := &entity.EntryMessage{EntryID: item.GUID, ChatID: -1000000000000, MessageID: 10, MessageType: entity.MessageTypeText}
+
+	msg, err := service.Edit(ctx, item, entry, message)
+	if assert.NoError(t.T(), err) {
+		assert.IsType(t.T(), tgbotapi.EditMessageTextConfig{}, msg)
+	}
+
+	item.ImageURL = server.URL + "/1.jpg"
+	_, err = service.Edit(ctx, item, entry, message)
+	assert.ErrorIs(t.T(), err, service.ErrRepostRequired)
+
+	message.MessageType = entity.MessageTypePhoto
+	entry.ImageURL = item.ImageURL
+	msg, err = service.Edit(ctx, item, entry, message)
+	if assert.NoError(t.T(), err) {
+		assert.IsType(t.T(), tgbotapi.EditMessageCaptionConfig{}, msg)
+	}
+
+	item.ImageURL = server.URL + "/2.jpg"
+	msg, err = service.Edit(ctx, item, entry, message)
+	if assert.NoError(t.T(), err) {
+		assert.IsType(t.T(), tgbotapi.EditMessageMediaConfig{}, msg)
+	}
+
+	item.Description = strings.Repeat("Long description. ", 100)
+	_, err = service.Edit(ctx, item, entry, message)
+	assert.ErrorIs(t.T(), err, service.ErrRepostRequired)
+
+	config.UpdatedMarker = true
+	defer func() { config.UpdatedMarker = false }()
+	item.ImageURL = ""
+	message.MessageType = entity.MessageTypeText
+	msg, err = service.Edit(ctx, item, entry, message)
+	if assert.NoError(t.T(), err) && assert.IsType(t.T(), tgbotapi.EditMessageTextConfig{}, msg) {
+		assert.Contains(t.T(), msg.(tgbotapi.EditMessageTextConfig).Text, "✏️ updated "+time.Now().In(config.TimeZone).Format("15:04"))
+	}
+}
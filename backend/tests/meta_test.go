@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Meta_ParseMeta() {
+	link := "https://www.err.ee/1609000010/valitsus-kinnitas-lisaeelarve?utm_source=rss"
+	meta, err := service.ParseMeta(link, readFixture(t, "err_news_article.html"), nil)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "Valitsus kinnitas lisaeelarve", meta.Title)
+		assert.Equal(t.T(), "Valitsus kinnitas neljapäeval riigi lisaeelarve eelnõu.", meta.Description)
+		assert.Equal(t.T(), "https://s.err.ee/photo/crop/2026/10/18/lisaeelarve.jpg", meta.ImageURL)
+		assert.Equal(t.T(), "Mari Maasikas, Jaan Tamm", meta.Author)
+		assert.Equal(t.T(), "Eesti", meta.Section)
+		if assert.NotNil(t.T(), meta.Published) {
+			assert.Equal(t.T(), time.Date(2026, 10, 18, 6, 15, 0, 0, time.UTC), meta.Published.UTC())
+		}
+		assert.False(t.T(), meta.Paywall)
+	}
+
+	meta, err = service.ParseMeta("https://www.delfi.ee/artikkel/120000010/tartu-kool", readFixture(t, "delfi_twitter_article.html"), nil)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "Tartu linnavalitsus avas uue kooli", meta.Title)
+		assert.Equal(t.T(), "Tartus avati uus põhikool.", meta.Description)
+		assert.Equal(t.T(), "https://www.delfi.ee/images/tartu-kool.jpg", meta.ImageURL)
+		assert.Equal(t.T(), "Peeter Kask", meta.Author)
+		assert.NotNil(t.T(), meta.Published)
+	}
+
+	meta, err = service.ParseMeta("https://rus.postimees.ee/8000010/novosti", readFixture(t, "postimees_no_image.html"), nil)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "Новости Таллинна", meta.Title)
+		assert.Empty(t.T(), meta.ImageURL)
+		assert.Empty(t.T(), meta.Description)
+		assert.Nil(t.T(), meta.Published)
+		assert.True(t.T(), meta.Paywall)
+	}
+}
+
+func (t *SuiteTest) Test_Meta_ApplyMeta() {
+	published := time.Date(2026, 10, 18, 9, 15, 0, 0, time.UTC)
+	item := &config.FeedItem{Title: "Pealkiri", ImageURL: "https://example.com/feed.jpg"}
+	service.ApplyMeta(item, &service.Meta{
+		Title:       "Other title",
+		Description: "Kirjeldus",
+		Author:      "Mari Maasikas",
+		Section:     "Eesti",
+		Published:   &published,
+	})
+	assert.Equal(t.T(), "Pealkiri", item.Title)
+	assert.Empty(t.T(), item.ImageURL)
+	assert.Equal(t.T(), "Kirjeldus", item.Description)
+	assert.Equal(t.T(), "Mari Maasikas", item.Author)
+	assert.Equal(t.T(), []string{"Eesti"}, item.Categories)
+	assert.Equal(t.T(), "Sun, 18 Oct 2026 09:15:00 +0000", item.Published)
+
+	item = &config.FeedItem{Description: "Feed", Categories: []string{"Sport"}, Published: "Sat, 17 Oct 2026 09:15:00 +0000"}
+	service.ApplyMeta(item, &service.Meta{Description: "Meta", Section: "Eesti", Published: &published, Paywall: true})
+	assert.Equal(t.T(), "Feed", item.Description)
+	assert.Equal(t.T(), []string{"Sport"}, item.Categories)
+	assert.Equal(t.T(), "Sat, 17 Oct 2026 09:15:00 +0000", item.Published)
+	assert.True(t.T(), item.Paywall)
+}
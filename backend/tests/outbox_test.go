@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Outbox_EnqueueSend() {
+	LoadFixtures(t)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	item := &config.FeedItem{GUID: "err#123-1000000000000", Title: "first"}
+	assert.NoError(t.T(), service.EnqueueSend(ctx, item, channel.ID))
+	assert.NoError(t.T(), service.EnqueueSend(ctx, &config.FeedItem{GUID: item.GUID, Title: "second"}, channel.ID))
+
+	messages, err := service.ClaimDueOutboxMessages(ctx, time.Now())
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 1, len(messages)) {
+		assert.Equal(t.T(), entity.OutboxSend, messages[0].Operation)
+		assert.Equal(t.T(), "second", messages[0].Item.Title)
+		assert.Equal(t.T(), "ERR", messages[0].FeedTitle)
+		assert.Equal(t.T(), "est", messages[0].Channel.Name)
+		assert.NoError(t.T(), service.MarkOutboxSent(ctx, messages[0]))
+	}
+	assert.NoError(t.T(), service.EnqueueSend(ctx, &config.FeedItem{GUID: item.GUID, Title: "third"}, channel.ID))
+	messages, err = service.ClaimDueOutboxMessages(ctx, time.Now())
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), messages)
+	}
+}
+
+func (t *SuiteTest) Test_Outbox_ClaimDueOutboxMessages_MarkOutboxFailure() {
+	LoadFixtures(t)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	item := &config.FeedItem{GUID: "err#123-1000000000000", Title: "title"}
+	message := &entity.EntryMessage{EntryID: item.GUID, ChannelID: channel.ID, ChatID: channel.ChatID, MessageID: 10}
+	assert.NoError(t.T(), service.EnqueueRepost(ctx, item, message))
+	assert.NoError(t.T(), service.EnqueueSend(ctx, &config.FeedItem{GUID: "err#321-1000000000000"}, channel.ID))
+
+	now := time.Now()
+	messages, err := service.ClaimDueOutboxMessages(ctx, now)
+	if !assert.NoError(t.T(), err) || !assert.Equal(t.T(), 2, len(messages)) {
+		return
+	}
+	assert.Equal(t.T(), entity.OutboxSend, messages[0].Operation)
+	assert.Equal(t.T(), 10, messages[0].MessageID)
+	assert.Equal(t.T(), 1, messages[0].Attempts)
+	assert.Equal(t.T(), "err#321-1000000000000", messages[1].EntryID)
+	claimed, err := service.ClaimDueOutboxMessages(ctx, now)
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), claimed)
+	}
+
+	defer func(attempts int) { config.OutboxMaxAttempts = attempts }(config.OutboxMaxAttempts)
+	config.OutboxMaxAttempts = 2
+	failed, err := service.MarkOutboxFailure(ctx, messages[0], errors.New("timeout"), now)
+	assert.NoError(t.T(), err)
+	assert.False(t.T(), failed)
+	assert.Equal(t.T(), now.Add(config.OutboxRetryDelay), messages[0].NextAttemptAt)
+
+	now = now.Add(config.OutboxRetryDelay)
+	messages, err = service.ClaimDueOutboxMessages(ctx, now)
+	if !assert.NoError(t.T(), err) || !assert.Equal(t.T(), 1, len(messages)) {
+		return
+	}
+	assert.Equal(t.T(), item.GUID, messages[0].EntryID)
+	assert.Equal(t.T(), 2, messages[0].Attempts)
+	failed, err = service.MarkOutboxFailure(ctx, messages[0], errors.New("timeout"), now)
+	assert.NoError(t.T(), err)
+	assert.True(t.T(), failed)
+
+	messages, err = service.ClaimDueOutboxMessages(ctx, now)
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 1, len(messages)) {
+		assert.Equal(t.T(), entity.OutboxDelete, messages[0].Operation)
+	}
+}
+
+func (t *SuiteTest) Test_Outbox_GetInterruptedOutboxMessages() {
+	LoadFixtures(t)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	assert.NoError(t.T(), service.EnqueueSend(ctx, &config.FeedItem{GUID: "err#123-1000000000000"}, channel.ID))
+
+	now := time.Now()
+	messages, err := service.ClaimDueOutboxMessages(ctx, now)
+	if !assert.NoError(t.T(), err) || !assert.Equal(t.T(), 1, len(messages)) {
+		return
+	}
+	assert.NoError(t.T(), service.MarkOutboxSending(ctx, messages[0]))
+	interrupted, err := service.GetInterruptedOutboxMessages(ctx, now)
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), interrupted)
+	}
+
+	now = now.Add(config.OutboxLease + time.Minute)
+	messages, err = service.ClaimDueOutboxMessages(ctx, now)
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), messages)
+	}
+	interrupted, err = service.GetInterruptedOutboxMessages(ctx, now)
+	if !assert.NoError(t.T(), err) || !assert.Equal(t.T(), 1, len(interrupted)) {
+		return
+	}
+	assert.Equal(t.T(), "est", interrupted[0].Channel.Name)
+	assert.NoError(t.T(), service.MarkOutboxInterrupted(ctx, interrupted[0], "sending is interrupted", now))
+	interrupted, err = service.GetInterruptedOutboxMessages(ctx, now)
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), interrupted)
+	}
+}
+
+func (t *SuiteTest) Test_Outbox_EnqueueEdit() {
+	LoadFixtures(t)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	entry := entity.Entry{ID: "err#123-1000000000000", ImageURL: "https://err.ee/1.jpg"}
+	message := &entity.EntryMessage{EntryID: entry.ID, ChannelID: channel.ID, ChatID: channel.ChatID, MessageID: 10}
+	editAt := time.Now().Add(config.MinEditInterval)
+	version := strconv.FormatInt(editAt.UnixNano(), 10)
+	assert.NoError(t.T(), service.EnqueueEdit(ctx, &config.FeedItem{GUID: entry.ID, Title: "first"}, entry, message, version, editAt))
+	assert.NoError(t.T(), service.EnqueueEdit(ctx, &config.FeedItem{GUID: entry.ID, Title: "second"}, entry, message, version, editAt))
+
+	messages, err := service.ClaimDueOutboxMessages(ctx, time.Now())
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), messages)
+	}
+	messages, err = service.ClaimDueOutboxMessages(ctx, editAt)
+	if assert.NoError(t.T(), err) && assert.Equal(t.T(), 1, len(messages)) {
+		assert.Equal(t.T(), entity.OutboxEdit, messages[0].Operation)
+		assert.Equal(t.T(), "second", messages[0].Item.Title)
+		assert.Equal(t.T(), entry.ImageURL, messages[0].ImageURL)
+	}
+}
+
+func (t *SuiteTest) Test_Outbox_DeliverOutboxSend() {
+	LoadFixtures(t)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	assert.NoError(t.T(), service.EnqueueSend(ctx, &config.FeedItem{GUID: "err#123-1000000000000"}, channel.ID))
+	defer func(timeout time.Duration) { config.TimeoutBetweenMessages = timeout }(config.TimeoutBetweenMessages)
+	config.TimeoutBetweenMessages = 0
+
+	sends := 0
+	send := func() (*tgbotapi.Message, error) {
+		sends++
+		return &tgbotapi.Message{MessageID: 10}, nil
+	}
+	record := func(sendedMsg *tgbotapi.Message) error {
+		return service.UpsertRecordMessage(ctx, &entity.EntryMessage{EntryID: "missing#1", ChannelID: channel.ID, ChatID: channel.ChatID, MessageID: sendedMsg.MessageID})
+	}
+	now := time.Now()
+	for range 2 {
+		messages, err := service.ClaimDueOutboxMessages(ctx, now)
+		if !assert.NoError(t.T(), err) {
+			return
+		}
+		for _, message := range messages {
+			_, err := service.DeliverOutboxSend(ctx, message, send, record)
+			assert.ErrorIs(t.T(), err, service.ErrSentNotRecorded)
+		}
+		now = now.Add(config.OutboxLease + time.Minute)
+	}
+	assert.Equal(t.T(), 1, sends)
+	interrupted, err := service.GetInterruptedOutboxMessages(ctx, now)
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), interrupted)
+	}
+	var message entity.OutboxMessage
+	assert.NoError(t.T(), t.db.NewSelect().Model(&message).Where("entry_id = ?", "err#123-1000000000000").Scan(ctx))
+	assert.False(t.T(), message.FailedAt.IsZero())
+	assert.Contains(t.T(), message.LastError, "message 10 is sent but not recorded")
+}
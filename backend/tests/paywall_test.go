@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Paywall_ParseMeta() {
+	link := "https://www.postimees.ee/8000020/eesti-majandus-kasvas"
+	premium := readFixture(t, "postimees_premium.html")
+	for _, c := range []struct {
+		rules   *entity.PaywallRules
+		body    []byte
+		paywall bool
+	}{
+		{nil, premium, true},
+		{&entity.PaywallRules{AccessibleForFree: true}, premium, true},
+		{&entity.PaywallRules{Classes: []string{"article-body--premium"}}, premium, true},
+		{&entity.PaywallRules{Classes: []string{"premium"}}, premium, false},
+		{&entity.PaywallRules{}, premium, false},
+		{nil, readFixture(t, "err_news_article.html"), false},
+		{nil, readFixture(t, "postimees_no_image.html"), true},
+		{nil, readFixture(t, "delfi_premium.html"), true},
+		{&entity.PaywallRules{Classes: []string{"C-paywall"}}, readFixture(t, "delfi_premium.html"), true},
+		{&entity.PaywallRules{Patterns: []string{`"isPremium"\s*:\s*true`}}, readFixture(t, "delfi_premium.html"), true},
+		{&entity.PaywallRules{Patterns: []string{`"isPremium":true`, `(`}}, readFixture(t, "delfi_premium.html"), false},
+		{&entity.PaywallRules{AccessibleForFree: true}, readFixture(t, "err_news_article.html"), false},
+	} {
+		meta, err := service.ParseMeta(link, c.body, c.rules)
+		if assert.NoError(t.T(), err) {
+			assert.Equal(t.T(), c.paywall, meta.Paywall, "%+v", c.rules)
+		}
+	}
+}
+
+func (t *SuiteTest) Test_Paywall_GetChatID() {
+	provider := &entity.Provider{PaywallPolicy: entity.PaywallPublish, PaywallChatID: -2000}
+	ctx := context.WithValue(context.Background(), config.CtxProviderKey, provider)
+	ctx = context.WithValue(ctx, config.CtxChatIDKey, int64(-1000))
+	assert.Equal(t.T(), int64(-1000), service.GetChatID(ctx, true))
+	assert.False(t.T(), service.IsPaywallSkipped(ctx, &config.FeedItem{Paywall: true}))
+
+	provider.PaywallPolicy = entity.PaywallRoute
+	assert.Equal(t.T(), int64(-2000), service.GetChatID(ctx, true))
+	assert.Equal(t.T(), int64(-1000), service.GetChatID(ctx, false))
+	channelCtx := context.WithValue(ctx, config.CtxChannelKey, &entity.Channel{ChatID: -1000, PaywallChatID: -3000})
+	assert.Equal(t.T(), int64(-3000), service.GetChatID(channelCtx, true))
+	assert.Equal(t.T(), int64(-1000), service.GetChatID(channelCtx, false))
+
+	provider.PaywallPolicy = entity.PaywallSkip
+	assert.Equal(t.T(), int64(-1000), service.GetChatID(ctx, true))
+	assert.True(t.T(), service.IsPaywallSkipped(ctx, &config.FeedItem{Paywall: true}))
+	assert.False(t.T(), service.IsPaywallSkipped(ctx, &config.FeedItem{}))
+}
+
+func (t *SuiteTest) Test_Paywall_SetProviderPaywallPolicy() {
+	LoadFixtures(t)
+	provider := t.ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	assert.Error(t.T(), entity.SetProviderPaywallPolicy(t.ctx, provider.ID, "hide", 0))
+	assert.Error(t.T(), entity.SetProviderPaywallPolicy(t.ctx, provider.ID, entity.PaywallRoute, 0))
+	assert.NoError(t.T(), entity.SetProviderPaywallPolicy(t.ctx, provider.ID, entity.PaywallRoute, -2000))
+	assert.NoError(t.T(), entity.SetProviderPaywall(t.ctx, provider.ID, &entity.PaywallRules{Classes: []string{"paywall"}}))
+
+	var stored entity.Provider
+	assert.NoError(t.T(), t.db.NewSelect().Model(&stored).Where("id = ?", provider.ID).Scan(t.ctx))
+	assert.Equal(t.T(), entity.PaywallRoute, stored.PaywallPolicy)
+	assert.Equal(t.T(), int64(-2000), stored.PaywallChatID)
+	assert.Equal(t.T(), []string{"paywall"}, stored.Paywall.Classes)
+
+	ctx := context.WithValue(t.ctx, config.CtxProviderKey, &stored)
+	ctx = context.WithValue(ctx, config.CtxFeedTitleKey, "ERR")
+	ctx = context.WithValue(ctx, config.CtxTranslateLangKey, "")
+	item := &config.FeedItem{GUID: "err#123-1000000000000", Link: "https://err.ee/123", Title: "Title", Description: "Description", Paywall: true}
+	_, err := service.Edit(ctx, item, entity.Entry{ID: item.GUID}, &entity.EntryMessage{ChatID: -1000000000000, MessageID: 10, MessageType: entity.MessageTypeText})
+	assert.ErrorIs(t.T(), err, service.ErrRepostRequired)
+	_, err = service.Edit(ctx, item, entity.Entry{ID: item.GUID}, &entity.EntryMessage{ChatID: -2000, MessageID: 10, MessageType: entity.MessageTypeText})
+	assert.NoError(t.T(), err)
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"sync"
+	"time"
+
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Pool_FetchPool() {
+	var mu sync.Mutex
+	fetches := 0
+	pool := service.NewFetchPool(2, func(provider *entity.Provider) (*gofeed.Feed, error) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		return &gofeed.Feed{Title: provider.URL}, nil
+	})
+	provider := entity.Provider{ID: 1, URL: "err.ee"}
+	assert.True(t.T(), pool.Submit(provider))
+	assert.True(t.T(), pool.IsFetching(provider.ID))
+	for range 3 {
+		assert.False(t.T(), pool.Submit(provider))
+		time.Sleep(10 * time.Millisecond)
+	}
+	result := <-pool.Results()
+	assert.Equal(t.T(), "err.ee", result.Feed.Title)
+	assert.False(t.T(), pool.Submit(provider))
+	pool.Done(provider.ID)
+	assert.False(t.T(), pool.IsFetching(provider.ID))
+
+	assert.True(t.T(), pool.Submit(provider))
+	assert.True(t.T(), pool.Submit(entity.Provider{ID: 2, URL: "pm.ee"}))
+	for range 2 {
+		result = <-pool.Results()
+		pool.Done(result.Provider.ID)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t.T(), 3, fetches)
+}
@@ -38,7 +38,7 @@ func (t *SuiteTest) Test_Record_UpsertRecord_Create() {
 		Title:       "title",
 		Description: "description",
 		Categories:  []string{"cat1", "cat3"},
-	}, 123)
+	}, "")
 	if assert.NoError(t.T(), err) {
 		var entries []entity.Entry
 		err = t.db.NewSelect().Model(&entries).Scan(t.ctx)
@@ -75,7 +75,7 @@ func (t *SuiteTest) Test_Record_UpsertRecord_Update() {
 		Title:       "title",
 		Description: "description",
 		Categories:  []string{"cat1", "cat3"},
-	}, 123)
+	}, "")
 	assert.NoError(t.T(), err)
 	err = service.UpsertRecord(t.ctx, &config.FeedItem{
 		GUID:       "pm#123-1000000000000",
@@ -83,7 +83,7 @@ func (t *SuiteTest) Test_Record_UpsertRecord_Update() {
 		Published:  "Mon, 02 Jan 2006 15:04:05 -0700",
 		Title:      "title",
 		Categories: []string{"cat1", "cat2"},
-	}, 123)
+	}, "")
 	if assert.NoError(t.T(), err) {
 		var entries []entity.Entry
 		err = t.db.NewSelect().Model(&entries).Scan(t.ctx)
@@ -156,3 +156,34 @@ func (t *SuiteTest) Test_Record_AddMissedCategories() {
 		assert.Equal(t.T(), categories[4].ID, categoriesMap["cat3"])
 	}
 }
+
+func (t *SuiteTest) Test_Record_UpsertRecord_Revisions() {
+	LoadFixtures(t)
+	item := &config.FeedItem{
+		GUID:      "pm#123-1000000000000",
+		Published: "Mon, 02 Jan 2006 15:04:05 -0700",
+		Link:      "link",
+		Title:     "title",
+	}
+	assert.NoError(t.T(), service.UpsertRecord(t.ctx, item, ""))
+	assert.NoError(t.T(), service.UpsertRecord(t.ctx, item, ""))
+	item.Title = "new title"
+	assert.NoError(t.T(), service.UpsertRecord(t.ctx, item, ""))
+
+	revisions, err := entity.GetEntryRevisions(t.ctx, item.GUID)
+	if assert.NoError(t.T(), err) && assert.Len(t.T(), revisions, 2) {
+		assert.Equal(t.T(), "title", revisions[0].Title)
+		assert.Equal(t.T(), "new title", revisions[1].Title)
+	}
+}
+
+func (t *SuiteTest) Test_Record_AddItemCategories() {
+	LoadFixtures(t)
+	item := &config.FeedItem{GUID: "err#456", Categories: []string{"cat1", "Eesti"}}
+	if assert.NoError(t.T(), service.AddItemCategories(t.ctx, item)) && assert.Equal(t.T(), 2, len(item.CategoriesIDs)) {
+		var category entity.Category
+		assert.NoError(t.T(), t.db.NewSelect().Model(&category).Where("name = ?", "cat1").Scan(t.ctx))
+		assert.Equal(t.T(), category.ID, item.CategoriesIDs[0])
+		assert.NotZero(t.T(), item.CategoriesIDs[1])
+	}
+}
@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"context"
+	"strings"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Retraction_AddRetractedEntry() {
+	LoadFixtures(t)
+	provider := t.ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	assert.NoError(t.T(), entity.SetProviderRetractionPolicy(t.ctx, provider.ID, entity.RetractionStrike))
+	assert.Error(t.T(), entity.SetProviderRetractionPolicy(t.ctx, provider.ID, "hide"))
+
+	var entry entity.Entry
+	assert.NoError(t.T(), t.db.NewSelect().Model(&entry).Where("id = ?", "err#123-1000000000000").Scan(t.ctx))
+	assert.NoError(t.T(), entity.AddRetractedEntry(t.ctx, entry, entity.RetractionStrike, "link is unavailable"))
+
+	assert.NoError(t.T(), t.db.NewSelect().Model(&entry).WherePK().Scan(t.ctx))
+	assert.False(t.T(), entry.RetractedAt.IsZero())
+	entries, err := entity.GetRetractedEntries(t.ctx, 10)
+	if assert.NoError(t.T(), err) && assert.Len(t.T(), entries, 1) {
+		assert.Equal(t.T(), entry.ID, entries[0].EntryID)
+		assert.Equal(t.T(), entity.RetractionStrike, entries[0].Policy)
+		assert.Equal(t.T(), provider.ID, entries[0].Provider.ID)
+	}
+}
+
+func (t *SuiteTest) Test_Retraction_Retract() {
+	LoadFixtures(t)
+	ctx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	ctx = context.WithValue(ctx, config.CtxTranslateLangKey, "")
+	entry := entity.Entry{ID: "err#123-1000000000000", Title: "Title", Description: "Description"}
+	msg, err := service.Retract(ctx, entry, &entity.EntryMessage{ChatID: -1000000000000, MessageID: 10, MessageType: entity.MessageTypeText})
+	if assert.NoError(t.T(), err) && assert.IsType(t.T(), tgbotapi.EditMessageTextConfig{}, msg) {
+		assert.Equal(t.T(), "<s><b>Title</b>\n\nDescription</s>\n\n⚠️ Article withdrawn", msg.(tgbotapi.EditMessageTextConfig).Text)
+	}
+
+	entry.ImageURL = "https://err.ee/1.jpg"
+	entry.Description = strings.Repeat("Long description. ", 56)
+	msg, err = service.Retract(ctx, entry, &entity.EntryMessage{ChatID: -1000000000000, MessageID: 10, MessageType: entity.MessageTypePhoto})
+	if assert.NoError(t.T(), err) && assert.IsType(t.T(), tgbotapi.EditMessageCaptionConfig{}, msg) {
+		caption := msg.(tgbotapi.EditMessageCaptionConfig).Caption
+		assert.LessOrEqual(t.T(), service.VisibleLength(caption), config.CaptionLimit)
+		assert.Contains(t.T(), caption, "Article withdrawn")
+	}
+}
@@ -0,0 +1,19 @@
+package tests
+
+import (
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Sanitize_SanitizeHTML() {
+	assert.Equal(t.T(), "Tom &amp; Jerry &lt;3 a &lt; b", service.SanitizeHTML("Tom & Jerry <3 a &lt; b"))
+	assert.Equal(t.T(), "<b>Bold</b> <i>italic</i> <b>strong</b> <code>x</code>", service.SanitizeHTML("<b>Bold</b> <em>italic</em> <strong>strong</strong> <code>x</code>"))
+	assert.Equal(t.T(), "First\n\nSecond\nline", service.SanitizeHTML("<p>First</p>\n  <p>Second<br>line</p>"))
+	assert.Equal(t.T(), "Text", service.SanitizeHTML(`<script>alert("x")</script><style>p { color: red; }</style>Text`))
+	assert.Equal(t.T(), "Unsupported <b>tags</b>", service.SanitizeHTML(`<div class="x"><span>Unsupported</span> <b><font>tags</font></b></div>`))
+	assert.Equal(t.T(), `<a href="https://err.ee/1?a=1&amp;b=2">link</a> unsafe`, service.SanitizeHTML(`<a href="https://err.ee/1?a=1&b=2" onclick="x">link</a> <a href="javascript:alert(1)">unsafe</a>`))
+	assert.Equal(t.T(), "<b><i>unclosed</i></b> text", service.SanitizeHTML("<b><i>unclosed</b> text</i>"))
+	assert.Equal(t.T(), "<blockquote>Quote</blockquote>\nAfter", service.SanitizeHTML("<blockquote>Quote</blockquote>After"))
+	assert.NoError(t.T(), service.ValidateTelegramHTML(service.SanitizeHTML(`<p>a <b>b <i>c</p> <img src="x"/> <a href=/relative>d</a>`)))
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Source_GetSource() {
+	for _, sourceType := range []string{"", entity.SourceRSS, entity.SourceJSON, entity.SourceSitemap, entity.SourceHTML} {
+		_, err := service.GetSource(&entity.Provider{Type: sourceType})
+		assert.NoError(t.T(), err)
+	}
+	_, err := service.GetSource(&entity.Provider{Type: "unknown"})
+	assert.Error(t.T(), err)
+}
+
+func (t *SuiteTest) Test_Source_ParseSitemap() {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:news="http://www.google.com/schemas/sitemap-news/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+	<url>
+		<loc>https://www.valitsus.ee/uudised/123</loc>
+		<news:news>
+			<news:publication><news:name>Valitsus</news:name><news:language>et</news:language></news:publication>
+			<news:publication_date>2006-01-02T15:04:05+02:00</news:publication_date>
+			<news:title>Valitsuse istung</news:title>
+			<news:keywords>Poliitika, Majandus</news:keywords>
+		</news:news>
+		<image:image><image:loc>https://www.valitsus.ee/image.jpg</image:loc></image:image>
+	</url>
+	<url>
+		<loc>https://www.valitsus.ee/kontakt</loc>
+	</url>
+</urlset>`
+	feed, err := service.ParseSitemap([]byte(body))
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "Valitsus", feed.Title)
+		assert.Len(t.T(), feed.Items, 1)
+		assert.Equal(t.T(), "https://www.valitsus.ee/uudised/123", feed.Items[0].Link)
+		assert.Equal(t.T(), "Valitsuse istung", feed.Items[0].Title)
+		assert.Equal(t.T(), []string{"Poliitika", "Majandus"}, feed.Items[0].Categories)
+		assert.Equal(t.T(), "https://www.valitsus.ee/image.jpg", feed.Items[0].Image.URL)
+		assert.Equal(t.T(), "Mon, 02 Jan 2006 15:04:05 +0200", feed.Items[0].Published)
+	}
+}
+
+func (t *SuiteTest) Test_Source_ParseHTMLListing() {
+	body := `<html><head><title>Pärnu Postimees</title></head><body>
+		<article class="news">
+			<a class="news__link" href="/uudised/123"><h2>Linnavolikogu   koosolek</h2></a>
+			<p class="news__lead">Volikogu arutas eelarvet.</p>
+			<time datetime="2006-01-02T15:04:05+02:00">2. jaanuar</time>
+			<span class="tag">Pärnu</span><span class="tag">Poliitika</span>
+		</article>
+		<article class="news">
+			<a class="news__link" href="https://parnu.postimees.ee/uudised/321"><h2>Rannahooaeg</h2></a>
+		</article>
+		<article class="news"><p>Reklaam</p></article>
+	</body></html>`
+	feed, err := service.ParseHTMLListing([]byte(body), "https://parnu.postimees.ee/", &entity.Selectors{
+		Item:        "article.news",
+		Title:       "h2",
+		Link:        "a.news__link",
+		Description: "p.news__lead",
+		Category:    "span.tag",
+		Published:   "time@datetime",
+	})
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "Pärnu Postimees", feed.Title)
+		assert.Len(t.T(), feed.Items, 2)
+		assert.Equal(t.T(), "https://parnu.postimees.ee/uudised/123", feed.Items[0].Link)
+		assert.Equal(t.T(), "Linnavolikogu koosolek", feed.Items[0].Title)
+		assert.Equal(t.T(), "Volikogu arutas eelarvet.", feed.Items[0].Description)
+		assert.Equal(t.T(), []string{"Pärnu", "Poliitika"}, feed.Items[0].Categories)
+		assert.Equal(t.T(), "Mon, 02 Jan 2006 15:04:05 +0200", feed.Items[0].Published)
+		assert.Equal(t.T(), "https://parnu.postimees.ee/uudised/321", feed.Items[1].Link)
+		assert.Empty(t.T(), feed.Items[1].Published)
+		assert.Nil(t.T(), feed.Items[1].PublishedParsed)
+	}
+}
+
+func (t *SuiteTest) Test_Source_DateListingItems() {
+	LoadFixtures(t)
+	var providers []entity.Provider
+	_ = t.db.NewSelect().Model(&providers).Scan(t.ctx)
+	dated := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+	newFeed := func() *gofeed.Feed {
+		return &gofeed.Feed{Items: []*gofeed.Item{
+			{Link: "https://parnu.postimees.ee/uudised/123", PublishedParsed: &dated, Published: dated.Format(time.RFC1123Z)},
+			{Link: "https://parnu.postimees.ee/uudised/321"},
+		}}
+	}
+	firstPoll := time.Now().Add(-3 * time.Hour).Truncate(time.Second)
+	feed := newFeed()
+	err := service.DateListingItems(t.ctx, &providers[0], feed, firstPoll)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), dated.Format(time.RFC1123Z), feed.Items[0].Published)
+		assert.Equal(t.T(), firstPoll.Format(time.RFC1123Z), feed.Items[1].Published)
+	}
+	feed = newFeed()
+	err = service.DateListingItems(t.ctx, &providers[0], feed, time.Now())
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), firstPoll.Format(time.RFC1123Z), feed.Items[1].Published)
+	}
+	var items []entity.ListingItem
+	_ = t.db.NewSelect().Model(&items).Scan(t.ctx)
+	if assert.Len(t.T(), items, 1) {
+		assert.True(t.T(), items[0].LastSeenAt.After(firstPoll))
+	}
+}
+
+func (t *SuiteTest) Test_Source_FetchCached() {
+	content := "<rss></rss>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	provider := &entity.Provider{URL: server.URL}
+	body, err := service.FetchCached(provider)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), content, string(body))
+		assert.Equal(t.T(), `"v1"`, provider.ETag)
+		assert.NotEmpty(t.T(), provider.ContentHash)
+	}
+	_, err = service.FetchCached(provider)
+	assert.ErrorIs(t.T(), err, service.ErrNotModified)
+
+	provider.ETag = ""
+	_, err = service.FetchCached(provider)
+	assert.ErrorIs(t.T(), err, service.ErrNotModified)
+}
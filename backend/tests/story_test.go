@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"context"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Story_AddStorySource_GetStorySources() {
+	LoadFixtures(t)
+	var providers []entity.Provider
+	_ = t.db.NewSelect().Model(&providers).Scan(t.ctx)
+	entry := &entity.Entry{ID: "err#123-1000000000000"}
+	ctx := context.WithValue(t.ctx, config.CtxProviderKey, &providers[1])
+	item := &config.FeedItem{GUID: "pm#1-1000000000000", Link: "https://pm.ee/1", Title: "title"}
+	added, err := service.AddStorySource(ctx, entry, item)
+	if assert.NoError(t.T(), err) {
+		assert.True(t.T(), added)
+	}
+	added, err = service.AddStorySource(ctx, entry, item)
+	if assert.NoError(t.T(), err) {
+		assert.False(t.T(), added)
+	}
+	added, err = service.AddStorySource(ctx, entry, &config.FeedItem{GUID: "pm#2-1000000000000", Link: "https://pm.ee/2", Title: "title"})
+	if assert.NoError(t.T(), err) {
+		assert.True(t.T(), added)
+	}
+	sources, err := service.GetStorySources(t.ctx, entry.ID)
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), 2, len(sources))
+		assert.Equal(t.T(), "https://pm.ee/1", sources[0].Link)
+		assert.Equal(t.T(), providers[1].ID, sources[0].Provider.ID)
+	}
+	var stories []entity.Story
+	_ = t.db.NewSelect().Model(&stories).Scan(t.ctx)
+	assert.Equal(t.T(), 1, len(stories))
+	attached, err := service.IsStorySource(t.ctx, item)
+	if assert.NoError(t.T(), err) {
+		assert.True(t.T(), attached)
+	}
+	attached, err = service.IsStorySource(t.ctx, &config.FeedItem{GUID: "pm#3-1000000000000"})
+	if assert.NoError(t.T(), err) {
+		assert.False(t.T(), attached)
+	}
+}
+
+func (t *SuiteTest) Test_Story_FindSimilarEntry() {
+	LoadFixtures(t)
+	var providers []entity.Provider
+	_ = t.db.NewSelect().Model(&providers).Order("id").Scan(t.ctx)
+	_, err := t.db.NewUpdate().Model((*entity.Provider)(nil)).Set("lang = ?", "et").Where("TRUE").Exec(t.ctx)
+	assert.NoError(t.T(), err)
+	title := "Valitsus kinnitas riigi lisaeelarve"
+	_, err = t.db.NewUpdate().Model((*entity.Entry)(nil)).Set("title = ?", title).Where("id = ?", "err#123-1000000000000").Exec(t.ctx)
+	assert.NoError(t.T(), err)
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+
+	ctx := context.WithValue(t.ctx, config.CtxProviderKey, &providers[1])
+	item := &config.FeedItem{GUID: "pm#1-1000000000000", Title: title}
+	entry, err := service.FindSimilarEntry(ctx, item)
+	if assert.NoError(t.T(), err) {
+		assert.Nil(t.T(), entry)
+	}
+
+	sendCtx := context.WithValue(t.ctx, config.CtxFeedTitleKey, "ERR")
+	assert.NoError(t.T(), service.EnqueueSend(sendCtx, &config.FeedItem{GUID: "err#123-1000000000000", Title: title}, channel.ID))
+	entry, err = service.FindSimilarEntry(ctx, item)
+	if assert.NoError(t.T(), err) && assert.NotNil(t.T(), entry) {
+		assert.Equal(t.T(), "err#123-1000000000000", entry.ID)
+	}
+
+	_, err = t.db.NewUpdate().Model((*entity.Entry)(nil)).Set("retracted_at = NOW()").Where("id = ?", "err#123-1000000000000").Exec(t.ctx)
+	assert.NoError(t.T(), err)
+	entry, err = service.FindSimilarEntry(ctx, item)
+	if assert.NoError(t.T(), err) {
+		assert.Nil(t.T(), entry)
+	}
+}
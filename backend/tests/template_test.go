@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"context"
+
+	"estonia-news/config"
+	"estonia-news/entity"
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Template_ValidateTelegramHTML() {
+	assert.NoError(t.T(), service.ValidateTelegramHTML(`<b>Title</b> <a href="https://err.ee">link</a> <span class="tg-spoiler">x</span> <blockquote expandable>quote</blockquote>`))
+	assert.Error(t.T(), service.ValidateTelegramHTML(`<div>Title</div>`))
+	assert.Error(t.T(), service.ValidateTelegramHTML(`<b>Title`))
+	assert.Error(t.T(), service.ValidateTelegramHTML(`<b><i>Title</b></i>`))
+	assert.Error(t.T(), service.ValidateTelegramHTML(`<a href="https://err.ee" onclick="x">link</a>`))
+	assert.Error(t.T(), service.ValidateTelegramHTML(`Title<br/>`))
+}
+
+func (t *SuiteTest) Test_Template_ValidateTemplate() {
+	assert.NoError(t.T(), service.ValidateTemplate(entity.TemplateText, service.DefaultTextTemplate))
+	assert.NoError(t.T(), service.ValidateTemplate(entity.TemplateButton, service.DefaultButtonTemplate))
+	assert.NoError(t.T(), service.ValidateTemplate(entity.TemplateText, `<i>{{.FeedTitle}}</i> {{.Published.Format "15:04"}}
+<b>{{escape .Title}}</b>{{if .Categories}} {{join .Categories ", "}}{{end}}`))
+	assert.NoError(t.T(), service.ValidateTemplate(entity.TemplateText, `<a href="{{.Link}}">{{.Provider}}</a>`))
+	assert.Error(t.T(), service.ValidateTemplate(entity.TemplateText, `<b>{{.Title}}`))
+	assert.Error(t.T(), service.ValidateTemplate(entity.TemplateText, `{{.Unknown}}`))
+	assert.Error(t.T(), service.ValidateTemplate(entity.TemplateText, `{{.Title`))
+	assert.Error(t.T(), service.ValidateTemplate(entity.TemplateButton, `{{if false}}x{{end}}`))
+	assert.Error(t.T(), service.ValidateTemplate("footer", `{{.Title}}`))
+}
+
+func (t *SuiteTest) Test_Template_RenderTemplate() {
+	LoadFixtures(t)
+	provider := t.ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	provider.Name = "ERR"
+	provider.Lang = "EST"
+	data := &service.TemplateData{Title: "Pealkiri", Description: "Kirjeldus", Provider: "ERR", Lang: "EST", Paywall: true}
+
+	assert.Equal(t.T(), "<b>Pealkiri</b>\n\nKirjeldus", service.RenderTemplate(t.ctx, entity.TemplateText, data))
+	assert.Equal(t.T(), "💰Loe edasi ERR", service.RenderTemplate(t.ctx, entity.TemplateButton, data))
+
+	assert.NoError(t.T(), entity.SetTemplate(t.ctx, &entity.Template{Name: entity.TemplateText, Body: "{{.Title}}"}))
+	assert.NoError(t.T(), entity.SetTemplate(t.ctx, &entity.Template{ProviderID: provider.ID, Name: entity.TemplateText, Body: "<i>{{.Title}}</i>"}))
+	channel := &entity.Channel{Name: "est", ChatID: -100, SourceLangs: []string{"et"}}
+	assert.NoError(t.T(), entity.AddChannel(t.ctx, channel))
+	assert.NoError(t.T(), entity.SetTemplate(t.ctx, &entity.Template{ProviderID: provider.ID, ChannelID: channel.ID, Name: entity.TemplateText, Body: "channel est"}))
+	assert.Equal(t.T(), "<i>Pealkiri</i>", service.RenderTemplate(t.ctx, entity.TemplateText, data))
+	assert.Equal(t.T(), "channel est", service.RenderTemplate(context.WithValue(t.ctx, config.CtxChannelKey, channel), entity.TemplateText, data))
+
+	assert.NoError(t.T(), entity.SetTemplate(t.ctx, &entity.Template{ProviderID: provider.ID, Name: entity.TemplateText, Body: "<u>{{.Title}}</u>"}))
+	assert.Equal(t.T(), "<u>Pealkiri</u>", service.RenderTemplate(t.ctx, entity.TemplateText, data))
+
+	data.Link = "https://news.err.ee/1?id=2&utm_source=rss"
+	data.Categories = []string{"Kultuur & <Kunst>"}
+	data.Provider = "ERR & Co"
+	assert.NoError(t.T(), entity.SetTemplate(t.ctx, &entity.Template{ProviderID: provider.ID, Name: entity.TemplateText, Body: `<a href="{{.Link}}">{{join .Categories ", "}}</a> {{.Provider}}`}))
+	text := service.RenderTemplate(t.ctx, entity.TemplateText, data)
+	assert.Equal(t.T(), `<a href="https://news.err.ee/1?id=2&amp;utm_source=rss">Kultuur &amp; &lt;Kunst&gt;</a> ERR &amp; Co`, text)
+	assert.NoError(t.T(), service.ValidateTelegramHTML(text))
+	assert.Equal(t.T(), "💰Loe edasi ERR & Co", service.RenderTemplate(t.ctx, entity.TemplateButton, data))
+
+	data.Hashtags = []string{"#politics", "#estonia"}
+	assert.NoError(t.T(), entity.SetTemplate(t.ctx, &entity.Template{ProviderID: provider.ID, Name: entity.TemplateText, Body: service.DefaultTextTemplate}))
+	assert.Equal(t.T(), "<b>Pealkiri</b>\n\nKirjeldus\n\n#politics #estonia", service.RenderTemplate(t.ctx, entity.TemplateText, data))
+
+	templates, err := entity.GetListTemplates(t.ctx)
+	if assert.NoError(t.T(), err) {
+		assert.Len(t.T(), templates, 3)
+		for _, template := range templates {
+			assert.NoError(t.T(), entity.DeleteTemplate(t.ctx, template.ID))
+		}
+	}
+}
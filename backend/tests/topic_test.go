@@ -0,0 +1,53 @@
+package tests
+
+import (
+	"estonia-news/config"
+	"estonia-news/entity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Topic_GetTopicsByCategories() {
+	LoadFixtures(t)
+	provider := t.ctx.Value(config.CtxProviderKey).(*entity.Provider)
+	assert.NoError(t.T(), entity.AddTopic(t.ctx, "Elections", "elections"))
+	assert.Error(t.T(), entity.AddTopic(t.ctx, "Bad", "#bad tag"))
+
+	topics, err := entity.GetTopics(t.ctx)
+	if !assert.NoError(t.T(), err) {
+		return
+	}
+	topicIDs := map[string]int{}
+	for _, topic := range topics {
+		topicIDs[topic.Name] = topic.ID
+	}
+	assert.Contains(t.T(), topics, entity.Topic{ID: topicIDs["Elections"], Name: "Elections", Hashtag: "#elections"})
+
+	categories, _, err := entity.GetCategoriesByProvider(t.ctx, provider.ID)
+	if !assert.NoError(t.T(), err) || !assert.Len(t.T(), categories, 2) {
+		return
+	}
+	assert.NoError(t.T(), entity.MapCategoryToTopic(t.ctx, categories[0].ID, topicIDs["Politics"]))
+	assert.NoError(t.T(), entity.MapCategoryToTopic(t.ctx, categories[0].ID, topicIDs["Politics"]))
+	assert.NoError(t.T(), entity.MapCategoryToTopic(t.ctx, categories[1].ID, topicIDs["Politics"]))
+	assert.NoError(t.T(), entity.MapCategoryToTopic(t.ctx, categories[1].ID, topicIDs["Estonia"]))
+
+	res, err := entity.GetTopicsByCategories(t.ctx, []int{categories[0].ID, categories[1].ID})
+	if assert.NoError(t.T(), err) {
+		assert.Len(t.T(), res, 2)
+	}
+	_, mapped, err := entity.GetCategoriesByProvider(t.ctx, provider.ID)
+	if assert.NoError(t.T(), err) {
+		assert.Len(t.T(), mapped[categories[1].ID], 2)
+	}
+
+	assert.NoError(t.T(), entity.UnmapCategoryFromTopic(t.ctx, categories[1].ID, topicIDs["Estonia"]))
+	res, err = entity.GetTopicsByCategories(t.ctx, []int{categories[1].ID})
+	if assert.NoError(t.T(), err) && assert.Len(t.T(), res, 1) {
+		assert.Equal(t.T(), "#politics", res[0].Hashtag)
+	}
+	res, err = entity.GetTopicsByCategories(t.ctx, nil)
+	if assert.NoError(t.T(), err) {
+		assert.Empty(t.T(), res)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Translate_LanguageCode() {
+	assert.Equal(t.T(), "et", service.LanguageCode("EST"))
+	assert.Equal(t.T(), "en", service.LanguageCode("eng"))
+	assert.Equal(t.T(), "pl", service.LanguageCode("PL"))
+}
+
+func (t *SuiteTest) Test_Translate_NewTranslator() {
+	for _, name := range []string{"", "google", "libre", "deepl"} {
+		_, err := service.NewTranslator(name, "", "")
+		assert.NoError(t.T(), err)
+	}
+	_, err := service.NewTranslator("unknown", "", "")
+	assert.Error(t.T(), err)
+}
+
+func (t *SuiteTest) Test_Translate_GoogleTranslator() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t.T(), "et", r.URL.Query().Get("sl"))
+		assert.Equal(t.T(), "en", r.URL.Query().Get("tl"))
+		_, _ = w.Write([]byte(`[[["First sentence. ","Esimene lause. ",null],["Second sentence.","Teine lause.",null]],null,"et"]`))
+	}))
+	defer server.Close()
+
+	text, err := service.NewGoogleTranslator(server.URL).Translate(t.ctx, "Esimene lause. Teine lause.", "et", "en")
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "First sentence. Second sentence.", text)
+	}
+}
+
+func (t *SuiteTest) Test_Translate_LibreTranslator() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]string
+		assert.NoError(t.T(), json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t.T(), "/translate", r.URL.Path)
+		assert.Equal(t.T(), "ru", request["source"])
+		assert.Equal(t.T(), "et", request["target"])
+		assert.Equal(t.T(), "secret", request["api_key"])
+		_, _ = w.Write([]byte(`{"translatedText":"Tere"}`))
+	}))
+	defer server.Close()
+
+	text, err := service.NewLibreTranslator(server.URL, "secret").Translate(t.ctx, "Привет", "ru", "et")
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "Tere", text)
+	}
+}
+
+func (t *SuiteTest) Test_Translate_DeepLTranslator() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t.T(), "/v2/translate", r.URL.Path)
+		assert.Equal(t.T(), "DeepL-Auth-Key secret", r.Header.Get("Authorization"))
+		assert.NoError(t.T(), r.ParseForm())
+		assert.Equal(t.T(), "ET", r.PostForm.Get("source_lang"))
+		assert.Equal(t.T(), "EN-GB", r.PostForm.Get("target_lang"))
+		_, _ = w.Write([]byte(`{"translations":[{"detected_source_language":"ET","text":"Hello"}]}`))
+	}))
+	defer server.Close()
+
+	text, err := service.NewDeepLTranslator(server.URL, "secret").Translate(t.ctx, "Tere", "et", "en")
+	if assert.NoError(t.T(), err) {
+		assert.Equal(t.T(), "Hello", text)
+	}
+}
+
+func (t *SuiteTest) Test_Translate_CachedTranslator() {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"translatedText":"Hello"}`))
+	}))
+	defer server.Close()
+
+	translator := service.NewCachedTranslator(service.NewLibreTranslator(server.URL, ""), t.db)
+	for range 2 {
+		text, err := translator.Translate(t.ctx, "Tere", "et", "en")
+		if assert.NoError(t.T(), err) {
+			assert.Equal(t.T(), "Hello", text)
+		}
+	}
+	assert.Equal(t.T(), 1, requests)
+}
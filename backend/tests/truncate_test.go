@@ -0,0 +1,29 @@
+package tests
+
+import (
+	"strings"
+
+	"estonia-news/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (t *SuiteTest) Test_Truncate_VisibleLength() {
+	assert.Equal(t.T(), 8, service.VisibleLength(`<b>Tom</b> &amp; <a href="https://err.ee">J</a>x`))
+	assert.Equal(t.T(), 2, service.VisibleLength("😀"))
+}
+
+func (t *SuiteTest) Test_Truncate_TruncateHTML() {
+	text := "<b>First sentence.</b> Second <i>sentence is here.</i> Third one"
+	assert.Equal(t.T(), text, service.TruncateHTML(text, 100))
+	assert.Equal(t.T(), "<b>First sentence.</b> Second <i>sentence is here.</i> …", service.TruncateHTML(text, 48))
+	assert.Equal(t.T(), "<b>First sentence.</b> …", service.TruncateHTML(text, 30))
+	assert.Equal(t.T(), "<b>First</b>…", service.TruncateHTML(text, 12))
+	assert.Equal(t.T(), "<b>Tom &amp;</b>…", service.TruncateHTML("<b>Tom &amp; Jerry</b>", 8))
+
+	long := strings.Repeat("Lause on siin. ", 100)
+	truncated := service.TruncateHTML(long, 1024)
+	assert.LessOrEqual(t.T(), service.VisibleLength(truncated), 1024)
+	assert.True(t.T(), strings.HasSuffix(truncated, "siin. …"))
+	assert.NoError(t.T(), service.ValidateTelegramHTML(service.TruncateHTML("<b>"+long+"</b>", 1024)))
+}